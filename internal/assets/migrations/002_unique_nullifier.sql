@@ -0,0 +1,22 @@
+-- +migrate Up
+-- The duplicates created before the index are failed, keeping the completed
+-- airdrop or the earliest one of each nullifier. Several completed airdrops
+-- of the same nullifier were paid twice, so they are left to be resolved
+-- manually and the index creation fails until then.
+UPDATE airdrops SET status = 'failed', updated_at = NOW()
+WHERE id IN (SELECT id
+             FROM (SELECT id,
+                          status,
+                          ROW_NUMBER() OVER (
+                              PARTITION BY nullifier
+                              ORDER BY status = 'completed' DESC, created_at, id
+                              ) AS n
+                   FROM airdrops
+                   WHERE status <> 'failed') ranked
+             WHERE n > 1
+               AND status <> 'completed');
+
+CREATE UNIQUE INDEX airdrops_nullifier_unique_idx ON airdrops (nullifier) WHERE status <> 'failed';
+
+-- +migrate Down
+DROP INDEX airdrops_nullifier_unique_idx;
@@ -0,0 +1,12 @@
+-- +migrate Up notransaction
+ALTER TYPE tx_status_enum ADD VALUE IF NOT EXISTS 'processing' AFTER 'pending';
+
+-- +migrate Down
+UPDATE airdrops SET status = 'pending' WHERE status = 'processing';
+
+DROP INDEX airdrops_nullifier_unique_idx;
+ALTER TYPE tx_status_enum RENAME TO tx_status_enum_old;
+CREATE TYPE tx_status_enum AS ENUM ('pending', 'completed', 'failed');
+ALTER TABLE airdrops ALTER COLUMN status TYPE tx_status_enum USING status::text::tx_status_enum;
+DROP TYPE tx_status_enum_old;
+CREATE UNIQUE INDEX airdrops_nullifier_unique_idx ON airdrops (nullifier) WHERE status <> 'failed';
@@ -0,0 +1,16 @@
+-- +migrate Up notransaction
+ALTER TYPE tx_status_enum ADD VALUE IF NOT EXISTS 'submitted' AFTER 'processing';
+ALTER TABLE airdrops ADD COLUMN IF NOT EXISTS tx_height bigint;
+ALTER TABLE airdrops ADD COLUMN IF NOT EXISTS tx_error text;
+
+-- +migrate Down
+UPDATE airdrops SET status = 'processing' WHERE status = 'submitted';
+ALTER TABLE airdrops DROP COLUMN tx_error;
+ALTER TABLE airdrops DROP COLUMN tx_height;
+
+DROP INDEX airdrops_nullifier_unique_idx;
+ALTER TYPE tx_status_enum RENAME TO tx_status_enum_old;
+CREATE TYPE tx_status_enum AS ENUM ('pending', 'processing', 'completed', 'failed');
+ALTER TABLE airdrops ALTER COLUMN status TYPE tx_status_enum USING status::text::tx_status_enum;
+DROP TYPE tx_status_enum_old;
+CREATE UNIQUE INDEX airdrops_nullifier_unique_idx ON airdrops (nullifier) WHERE status <> 'failed';
@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE airdrops RENAME COLUMN tx_error TO last_error;
+ALTER TABLE airdrops ADD COLUMN attempts        integer                     NOT NULL DEFAULT 0;
+ALTER TABLE airdrops ADD COLUMN next_attempt_at timestamp without time zone NOT NULL DEFAULT NOW();
+
+CREATE INDEX airdrops_status_next_attempt_at_idx ON airdrops (status, next_attempt_at);
+
+-- +migrate Down
+DROP INDEX airdrops_status_next_attempt_at_idx;
+
+ALTER TABLE airdrops DROP COLUMN next_attempt_at;
+ALTER TABLE airdrops DROP COLUMN attempts;
+ALTER TABLE airdrops RENAME COLUMN last_error TO tx_error;
@@ -0,0 +1,14 @@
+-- +migrate Up notransaction
+ALTER TYPE tx_status_enum ADD VALUE IF NOT EXISTS 'quarantined';
+
+-- +migrate Down
+UPDATE airdrops SET status = 'failed' WHERE status = 'quarantined';
+
+DROP INDEX airdrops_nullifier_unique_idx;
+DROP INDEX airdrops_status_next_attempt_at_idx;
+ALTER TYPE tx_status_enum RENAME TO tx_status_enum_old;
+CREATE TYPE tx_status_enum AS ENUM ('pending', 'processing', 'submitted', 'completed', 'failed');
+ALTER TABLE airdrops ALTER COLUMN status TYPE tx_status_enum USING status::text::tx_status_enum;
+DROP TYPE tx_status_enum_old;
+CREATE UNIQUE INDEX airdrops_nullifier_unique_idx ON airdrops (nullifier) WHERE status <> 'failed';
+CREATE INDEX airdrops_status_next_attempt_at_idx ON airdrops (status, next_attempt_at);
@@ -0,0 +1,11 @@
+-- +migrate Up
+CREATE TABLE sender_balances
+(
+    address         text PRIMARY KEY,
+    amount          text                        NOT NULL,
+    fundable_claims bigint                      NOT NULL,
+    updated_at      timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+-- +migrate Down
+DROP TABLE sender_balances;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE airdrops ADD COLUMN sender text;
+
+-- +migrate Down
+ALTER TABLE airdrops DROP COLUMN sender;
@@ -0,0 +1,19 @@
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION notify_airdrop_pending() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('airdrops_pending', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER airdrops_notify_pending
+    AFTER INSERT ON airdrops
+    FOR EACH ROW
+    WHEN (NEW.status = 'pending')
+EXECUTE FUNCTION notify_airdrop_pending();
+
+-- +migrate Down
+DROP TRIGGER IF EXISTS airdrops_notify_pending ON airdrops;
+DROP FUNCTION IF EXISTS notify_airdrop_pending();
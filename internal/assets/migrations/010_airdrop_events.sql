@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TYPE airdrop_actor_enum AS ENUM ('api', 'broadcaster', 'admin');
+
+CREATE TABLE airdrop_events
+(
+    id         bigserial PRIMARY KEY,
+    airdrop_id uuid                        NOT NULL REFERENCES airdrops (id) ON DELETE CASCADE,
+    old_status tx_status_enum,
+    new_status tx_status_enum              NOT NULL,
+    tx_hash    text,
+    error      text,
+    actor      airdrop_actor_enum          NOT NULL,
+    created_at timestamp without time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX airdrop_events_airdrop_id_idx ON airdrop_events (airdrop_id, id);
+
+-- +migrate Down
+DROP TABLE airdrop_events;
+DROP TYPE airdrop_actor_enum;
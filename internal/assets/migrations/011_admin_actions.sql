@@ -0,0 +1,20 @@
+-- +migrate Up notransaction
+ALTER TYPE tx_status_enum ADD VALUE IF NOT EXISTS 'cancelled';
+ALTER TABLE airdrop_events ADD COLUMN IF NOT EXISTS actor_id text;
+
+-- +migrate Down
+UPDATE airdrops SET status = 'failed' WHERE status = 'cancelled';
+UPDATE airdrop_events SET old_status = 'failed' WHERE old_status = 'cancelled';
+UPDATE airdrop_events SET new_status = 'failed' WHERE new_status = 'cancelled';
+ALTER TABLE airdrop_events DROP COLUMN actor_id;
+
+DROP INDEX airdrops_nullifier_unique_idx;
+DROP INDEX airdrops_status_next_attempt_at_idx;
+ALTER TYPE tx_status_enum RENAME TO tx_status_enum_old;
+CREATE TYPE tx_status_enum AS ENUM ('pending', 'processing', 'submitted', 'completed', 'failed', 'quarantined');
+ALTER TABLE airdrops ALTER COLUMN status TYPE tx_status_enum USING status::text::tx_status_enum;
+ALTER TABLE airdrop_events ALTER COLUMN old_status TYPE tx_status_enum USING old_status::text::tx_status_enum;
+ALTER TABLE airdrop_events ALTER COLUMN new_status TYPE tx_status_enum USING new_status::text::tx_status_enum;
+DROP TYPE tx_status_enum_old;
+CREATE UNIQUE INDEX airdrops_nullifier_unique_idx ON airdrops (nullifier) WHERE status <> 'failed';
+CREATE INDEX airdrops_status_next_attempt_at_idx ON airdrops (status, next_attempt_at);
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE INDEX airdrops_address_idx ON airdrops (address, created_at, id);
+
+-- +migrate Down
+DROP INDEX airdrops_address_idx;
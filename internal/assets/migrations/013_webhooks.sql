@@ -0,0 +1,41 @@
+-- +migrate Up
+CREATE TABLE webhook_outbox
+(
+    id            bigserial PRIMARY KEY,
+    event_id      bigint                      NOT NULL UNIQUE REFERENCES airdrop_events (id) ON DELETE CASCADE,
+    created_at    timestamp without time zone NOT NULL DEFAULT NOW(),
+    dispatched_at timestamp without time zone
+);
+
+CREATE INDEX webhook_outbox_undispatched_idx ON webhook_outbox (id) WHERE dispatched_at IS NULL;
+
+CREATE TYPE webhook_delivery_status_enum AS ENUM ('pending', 'delivered', 'dead');
+
+CREATE TABLE webhook_deliveries
+(
+    id              bigserial PRIMARY KEY,
+    event_id        bigint                       NOT NULL REFERENCES airdrop_events (id) ON DELETE CASCADE,
+    subscription    text                         NOT NULL,
+    status          webhook_delivery_status_enum NOT NULL DEFAULT 'pending',
+    attempts        integer                      NOT NULL DEFAULT 0,
+    next_attempt_at timestamp without time zone  NOT NULL DEFAULT NOW(),
+    last_error      text,
+    created_at      timestamp without time zone  NOT NULL DEFAULT NOW(),
+    updated_at      timestamp without time zone  NOT NULL DEFAULT NOW(),
+    UNIQUE (event_id, subscription)
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE VIEW webhook_dead_letters AS
+SELECT d.*, e.airdrop_id, e.new_status AS event_status, a.nullifier
+FROM webhook_deliveries d
+         JOIN airdrop_events e ON e.id = d.event_id
+         JOIN airdrops a ON a.id = e.airdrop_id
+WHERE d.status = 'dead';
+
+-- +migrate Down
+DROP VIEW webhook_dead_letters;
+DROP TABLE webhook_deliveries;
+DROP TYPE webhook_delivery_status_enum;
+DROP TABLE webhook_outbox;
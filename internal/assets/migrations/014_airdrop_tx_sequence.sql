@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE airdrops ADD COLUMN tx_sequence bigint;
+
+-- +migrate Down
+ALTER TABLE airdrops DROP COLUMN tx_sequence;
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- The dispatched outbox entries are deleted instead of being marked, so the
+-- outbox doesn't grow along with the events.
+DELETE FROM webhook_outbox WHERE dispatched_at IS NOT NULL;
+DROP INDEX webhook_outbox_undispatched_idx;
+ALTER TABLE webhook_outbox DROP COLUMN dispatched_at;
+
+-- +migrate Down
+ALTER TABLE webhook_outbox ADD COLUMN dispatched_at timestamp without time zone;
+CREATE INDEX webhook_outbox_undispatched_idx ON webhook_outbox (id) WHERE dispatched_at IS NULL;
@@ -0,0 +1,91 @@
+// Package auth authenticates the callers of privileged endpoints by static API
+// keys or JWTs issued by a trusted party.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ScopeAdmin grants access to the admin API
+const ScopeAdmin = "admin"
+
+// Authentication methods of the caller
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// ErrInvalidCredentials is returned when the token is unknown, malformed or
+// expired
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Caller is the authenticated identity
+type Caller struct {
+	// Name is the API key name or JWT subject
+	Name   string
+	Scopes []string
+	Method string
+}
+
+func (c Caller) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// APIKey is the static key, which is stored as hex-encoded SHA-256 hash
+type APIKey struct {
+	Name    string
+	KeyHash string
+	Scopes  []string
+}
+
+type Authenticator struct {
+	apiKeys map[string]APIKey
+	jwt     *JWTVerifier
+}
+
+// NewAuthenticator creates the authenticator by the API keys and optional JWT
+// verifier, which may be nil
+func NewAuthenticator(keys []APIKey, jwt *JWTVerifier) (*Authenticator, error) {
+	a := &Authenticator{
+		apiKeys: make(map[string]APIKey, len(keys)),
+		jwt:     jwt,
+	}
+
+	for _, key := range keys {
+		hash := strings.ToLower(key.KeyHash)
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("key hash of %s is not a hex SHA-256", key.Name)
+		}
+		if _, ok := a.apiKeys[hash]; ok {
+			return nil, fmt.Errorf("duplicated key hash of %s", key.Name)
+		}
+		a.apiKeys[hash] = key
+	}
+
+	return a, nil
+}
+
+// Authenticate returns the caller by the bearer token, which is either a JWT
+// or an API key
+func (a *Authenticator) Authenticate(token string) (*Caller, error) {
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		return a.jwt.Verify(token)
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	key, ok := a.apiKeys[hex.EncodeToString(hash[:])]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Caller{
+		Name:   key.Name,
+		Scopes: key.Scopes,
+		Method: MethodAPIKey,
+	}, nil
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "issuer"
+	testAudience = "airdrop-svc"
+	testAPIKey   = "api-key"
+)
+
+var hsSecret = []byte("secret")
+
+func writeKey(t *testing.T, raw []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+
+	return path
+}
+
+func newTestAuthenticator(t *testing.T, algorithm string, key []byte) *Authenticator {
+	t.Helper()
+
+	verifier, err := NewJWTVerifier(algorithm, writeKey(t, key), testIssuer, testAudience)
+	if err != nil {
+		t.Fatalf("create JWT verifier: %v", err)
+	}
+
+	hash := sha256.Sum256([]byte(testAPIKey))
+	a, err := NewAuthenticator([]APIKey{{
+		Name:    "partner",
+		KeyHash: hex.EncodeToString(hash[:]),
+		Scopes:  []string{ScopeAdmin},
+	}}, verifier)
+	if err != nil {
+		t.Fatalf("create authenticator: %v", err)
+	}
+
+	return a
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "admin",
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": ScopeAdmin,
+	}
+}
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	return mustSign(t, jwt.SigningMethodHS256, claims, hsSecret)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a := newTestAuthenticator(t, jwt.SigningMethodHS256.Alg(), hsSecret)
+
+	caller, err := a.Authenticate(testAPIKey)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if caller.Name != "partner" || caller.Method != MethodAPIKey || !caller.HasScope(ScopeAdmin) {
+		t.Fatalf("unexpected caller %+v", caller)
+	}
+
+	if _, err = a.Authenticate("unknown"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAuthenticateHS256(t *testing.T) {
+	a := newTestAuthenticator(t, jwt.SigningMethodHS256.Alg(), hsSecret)
+
+	caller, err := a.Authenticate(signHS256(t, validClaims()))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if caller.Name != "admin" || caller.Method != MethodJWT || !caller.HasScope(ScopeAdmin) {
+		t.Fatalf("unexpected caller %+v", caller)
+	}
+}
+
+func TestAuthenticateES256(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	a := newTestAuthenticator(t, jwt.SigningMethodES256.Alg(),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	claims := validClaims()
+	delete(claims, "scope")
+	claims["scopes"] = []string{ScopeAdmin, "read"}
+	caller, err := a.Authenticate(mustSign(t, jwt.SigningMethodES256, claims, key))
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if caller.Name != "admin" || !slices.Equal(caller.Scopes, []string{ScopeAdmin, "read"}) {
+		t.Fatalf("unexpected caller %+v", caller)
+	}
+}
+
+func TestAuthenticateInvalidJWT(t *testing.T) {
+	a := newTestAuthenticator(t, jwt.SigningMethodHS256.Alg(), hsSecret)
+
+	with := func(key string, value any) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	cases := map[string]string{
+		"wrong algorithm":   mustSign(t, jwt.SigningMethodHS384, validClaims(), hsSecret),
+		"wrong secret":      mustSign(t, jwt.SigningMethodHS256, validClaims(), []byte("other")),
+		"missing subject":   signHS256(t, with("sub", nil)),
+		"issuer mismatch":   signHS256(t, with("iss", "other")),
+		"audience mismatch": signHS256(t, with("aud", "other")),
+		"missing exp":       signHS256(t, with("exp", nil)),
+		"expired":           signHS256(t, with("exp", time.Now().Add(-time.Minute).Unix())),
+	}
+
+	for name, token := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := a.Authenticate(token); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+			}
+		})
+	}
+}
+
+func mustSign(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims, key any) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	return token
+}
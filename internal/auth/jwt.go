@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTVerifier verifies the tokens signed with HS256 shared secret or ES256
+// private key, which public key is known
+type JWTVerifier struct {
+	method jwt.SigningMethod
+	key    interface{}
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier reads the key from the file: the raw secret for HS256 or PEM
+// public key for ES256. Empty issuer and audience are not checked, while the
+// expiration is always required.
+func NewJWTVerifier(algorithm, keyPath, issuer, audience string) (*JWTVerifier, error) {
+	raw, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	v := &JWTVerifier{}
+
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret := bytes.TrimSpace(raw)
+		if len(secret) == 0 {
+			return nil, errors.New("empty HS256 secret")
+		}
+		v.method, v.key = jwt.SigningMethodHS256, secret
+	case jwt.SigningMethodES256.Alg():
+		v.method = jwt.SigningMethodES256
+		if v.key, err = jwt.ParseECPublicKeyFromPEM(raw); err != nil {
+			return nil, fmt.Errorf("parse ES256 public key: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s", algorithm)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{v.method.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		opts = append(opts, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify returns the caller from the token subject. The scopes are taken from
+// space-separated scope claim or scopes array.
+func (v *JWTVerifier) Verify(token string) (*Caller, error) {
+	var claims jwt.MapClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return v.key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, fmt.Errorf("%w: subject is missing", ErrInvalidCredentials)
+	}
+
+	return &Caller{
+		Name:   sub,
+		Scopes: parseScopes(claims),
+		Method: MethodJWT,
+	}, nil
+}
+
+func parseScopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	list, _ := claims["scopes"].([]interface{})
+	scopes := make([]string, 0, len(list))
+	for _, s := range list {
+		if str, ok := s.(string); ok {
+			scopes = append(scopes, str)
+		}
+	}
+
+	return scopes
+}
@@ -9,28 +9,47 @@ package broadcaster
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
+	"sync"
 	"time"
 
-	clienttx "github.com/cosmos/cosmos-sdk/client/tx"
 	"github.com/cosmos/cosmos-sdk/types"
-	client "github.com/cosmos/cosmos-sdk/types/tx"
-	"github.com/cosmos/cosmos-sdk/types/tx/signing"
-	xauthsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
-	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
-	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/rarimo/airdrop-svc/internal/config"
 	"github.com/rarimo/airdrop-svc/internal/data"
-	ethermint "github.com/rarimo/rarimo-core/ethermint/types"
+	"github.com/rarimo/airdrop-svc/internal/pubsub"
 	"gitlab.com/distributed_lab/logan/v3"
 	"gitlab.com/distributed_lab/running"
 )
 
 const txCodeSuccess = 0
 
+const (
+	// claimLease is the time the claimed pending airdrops are hidden from the
+	// other lanes. The expired claim is harmless, because the airdrops are
+	// moved to processing only if they are still pending.
+	claimLease = 2 * time.Minute
+	// senderPause is the time the lane doesn't claim the pending airdrops
+	// after the sender-side failure, e.g. of the remote signer
+	senderPause = time.Minute
+)
+
+// Runner is the broadcasting lane of a single sender. It is also used without
+// sender to confirm the submitted txs.
 type Runner struct {
-	log *logan.Entry
-	q   *data.AirdropsQ
+	log       *logan.Entry
+	q         *data.AirdropsQ
+	balancesQ *data.BalancesQ
+	seq       *sequenceManager
+	updates   *pubsub.Hub
+	sender    config.Sender
+	// primary lane also settles the airdrops sent before senders were recorded
+	primary bool
+	// pausedUntil is set on the sender-side failure, so the pending airdrops
+	// are left to the other lanes meanwhile
+	pausedUntil time.Time
 	config.Broadcaster
 }
 
@@ -38,193 +57,373 @@ func Run(ctx context.Context, cfg *config.Config) {
 	log := cfg.Log().WithField("service", "builtin-broadcaster")
 	log.Info("Starting service")
 
-	r := &Runner{
-		log:         log,
-		q:           data.NewAirdropsQ(cfg.DB().Clone()),
-		Broadcaster: cfg.Broadcaster(),
+	var (
+		broadcaster = cfg.Broadcaster()
+		lanes       []*Runner
+	)
+	for i, sender := range broadcaster.Senders {
+		lanes = append(lanes, &Runner{
+			log:         log.WithField("sender", sender.Address),
+			q:           data.NewAirdropsQ(cfg.DB().Clone(), data.ActorBroadcaster),
+			balancesQ:   data.NewBalancesQ(cfg.DB().Clone()),
+			seq:         newSequenceManager(broadcaster.Auth, sender.Address),
+			updates:     cfg.AirdropUpdates(),
+			sender:      sender,
+			primary:     i == 0,
+			Broadcaster: broadcaster,
+		})
 	}
+	log.Infof("Broadcasting with %d senders", len(lanes))
+
+	// only one replica of the service may broadcast, otherwise the same
+	// airdrops are sent twice and the sender sequences collide
+	runAsLeader(ctx, log, cfg.DB().RawDB(), func(ctx context.Context) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := &Runner{
+				log:         log.WithField("worker", "poller"),
+				q:           data.NewAirdropsQ(cfg.DB().Clone(), data.ActorBroadcaster),
+				updates:     cfg.AirdropUpdates(),
+				Broadcaster: broadcaster,
+			}
+			running.WithBackOff(ctx, p.log, "tx-confirmation-poller", p.poll, 5*time.Second, 5*time.Second, 5*time.Second)
+		}()
+
+		// each lane claims the pending airdrops on its own, so a slow sender,
+		// e.g. with unresponsive remote signer, doesn't delay the others
+		for _, lane := range lanes {
+			wg.Add(1)
+			go func(lane *Runner) {
+				defer wg.Done()
+
+				// the cached sequence may be outdated after another leader
+				lane.seq.reset()
+
+				listener := cfg.NewListener()
+				defer closeListener(lane.log, listener)
+
+				runOnNotify(ctx, lane.log, listener, lane.PollInterval, lane.run)
+			}(lane)
+		}
 
-	running.WithBackOff(ctx, r.log, "builtin-broadcaster", r.run, 5*time.Second, 5*time.Second, 5*time.Second)
+		wg.Wait()
+	})
 }
 
-func (r *Runner) run(ctx context.Context) error {
-	airdrops, err := r.q.New().FilterByStatus(data.TxStatusPending).Limit(r.QueryLimit).Select()
+// run broadcasts a page of pending airdrops claimed by the lane, reporting
+// whether there is some work left for the next run
+func (r *Runner) run(ctx context.Context) (bool, error) {
+	settled, err := r.reconcile(ctx)
 	if err != nil {
-		return fmt.Errorf("select airdrops: %w", err)
+		return false, fmt.Errorf("reconcile in-flight airdrops: %w", err)
 	}
-	if len(airdrops) == 0 {
-		return nil
+	if !settled {
+		r.log.Debug("Some airdrops are still in flight, waiting before sending new ones")
+		return true, nil
 	}
-	r.log.Debugf("Got %d pending airdrops, broadcasting now", len(airdrops))
 
-	for _, drop := range airdrops {
-		if err = r.handlePending(ctx, drop); err != nil {
-			r.log.WithField("airdrop", drop).
-				WithError(err).Error("Failed to handle pending airdrop")
-			continue
+	budget, err := r.availableBalance(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get available balance: %w", err)
+	}
+	r.saveBalance(budget)
+
+	if time.Now().Before(r.pausedUntil) {
+		r.log.Debugf("Lane is paused until %s", r.pausedUntil.Format(time.RFC3339))
+		return false, nil
+	}
+
+	// the lane that can't afford even the cheapest airdrop doesn't claim the
+	// pending ones, leaving them to the other lanes
+	affordable, err := r.canAffordPending(budget)
+	if err != nil {
+		return false, err
+	}
+	if !affordable {
+		return false, nil
+	}
+
+	airdrops, err := r.q.New().ClaimPending(r.QueryLimit, claimLease)
+	if err != nil {
+		return false, fmt.Errorf("claim pending airdrops: %w", err)
+	}
+	// the rest of pending airdrops is claimed on the next run
+	busy := uint64(len(airdrops)) == r.QueryLimit
+	if airdrops = r.quarantineInvalid(ctx, airdrops); len(airdrops) == 0 {
+		return busy, nil
+	}
+	r.log.Debugf("Claimed %d pending airdrops, broadcasting now", len(airdrops))
+
+	for len(airdrops) > 0 && ctx.Err() == nil {
+		size := min(len(airdrops), int(r.BatchSize))
+		batch := airdrops[:size]
+
+		cost := r.batchCost(batch)
+		if !budget.IsAllGTE(cost) {
+			// the rest is left to the other lanes or pending until the
+			// treasury is refilled
+			r.log.Warn("Sender balance is insufficient, pausing broadcasting")
+			if err = r.q.New().ReleaseClaim(airdropIDs(airdrops)); err != nil {
+				return false, fmt.Errorf("release claimed airdrops: %w", err)
+			}
+			return false, nil
+		}
+		budget = deduct(budget, cost)
+
+		airdrops = airdrops[size:]
+		if unsent, err := r.handleBatch(ctx, batch); err != nil {
+			r.pausedUntil = time.Now().Add(senderPause)
+			if releaseErr := r.q.New().ReleaseClaim(airdropIDs(slices.Concat(unsent, airdrops))); releaseErr != nil {
+				r.log.WithError(releaseErr).Error("Failed to release claimed airdrops")
+			}
+			return false, fmt.Errorf("sender failed, pausing the lane for %s: %w", senderPause, err)
 		}
 	}
 
-	return nil
+	return busy, nil
 }
 
-func (r *Runner) handlePending(ctx context.Context, airdrop data.Airdrop) (err error) {
-	var txHash string
+// quarantineInvalid sets quarantined status for the airdrops with invalid
+// amount and returns the valid ones
+func (r *Runner) quarantineInvalid(ctx context.Context, airdrops []data.Airdrop) []data.Airdrop {
+	valid := airdrops[:0]
 
-	defer func() {
-		if err != nil {
-			r.updateAirdropStatus(ctx, airdrop.ID, txHash, data.TxStatusFailed)
+	for _, drop := range airdrops {
+		if _, err := parseAmount(drop.Amount); err != nil {
+			r.log.WithField("airdrop", drop).WithError(err).Error("Quarantining airdrop with invalid amount")
+			r.updateAirdrops(ctx, []string{drop.ID}, map[string]any{
+				"status":     data.TxStatusQuarantined,
+				"last_error": err.Error(),
+			})
+			continue
 		}
-	}()
-
-	tx, err := r.createAirdropTx(ctx, airdrop)
-	if err != nil {
-		return fmt.Errorf("create airdrop tx: %w", err)
-	}
 
-	txHash, err = r.broadcastTx(ctx, tx)
-	if err != nil {
-		return fmt.Errorf("broadcast tx: %w", err)
+		valid = append(valid, drop)
 	}
 
-	r.updateAirdropStatus(ctx, airdrop.ID, txHash, data.TxStatusCompleted)
-	return nil
+	return valid
 }
 
-func (r *Runner) createAirdropTx(ctx context.Context, airdrop data.Airdrop) ([]byte, error) {
-	tx, err := r.genTx(ctx, 0, airdrop)
+// handleBatch sends the airdrops in a single tx. When the tx can't be created
+// because of a permanent error, e.g. one of the messages fails on simulation,
+// the batch is split in halves to isolate the bad airdrop and send the rest.
+// The sender-side errors don't depend on the airdrops, so the handling is
+// stopped and the error is returned along with the airdrops left unsent.
+func (r *Runner) handleBatch(ctx context.Context, batch []data.Airdrop) ([]data.Airdrop, error) {
+	account, err := r.seq.current(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate tx: %w", err)
+		return batch, fmt.Errorf("get sender account: %w", err)
 	}
 
-	gasUsed, err := r.simulateTx(ctx, tx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to simulate tx: %w", err)
+	tx, err := r.createAirdropTx(ctx, batch, account)
+	if errors.Is(err, errSigning) {
+		return batch, err
+	}
+	if err != nil && len(batch) > 1 && !isTransient(err) {
+		r.log.WithError(err).Warnf("Failed to create tx for %d airdrops, splitting the batch", len(batch))
+		first, second := batch[:len(batch)/2], batch[len(batch)/2:]
+		if unsent, err := r.handleBatch(ctx, first); err != nil {
+			return slices.Concat(unsent, second), err
+		}
+		return r.handleBatch(ctx, second)
 	}
 
-	tx, err = r.genTx(ctx, gasUsed*3, airdrop)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate tx after simulation: %w", err)
+		r.failAirdrops(ctx, batch, err)
+	} else {
+		err = r.sendAirdropTx(ctx, batch, tx, account.sequence)
+	}
+	if err != nil {
+		if isSequenceMismatch(err) {
+			r.log.Warn("Sender account sequence mismatch, resyncing")
+			r.seq.reset()
+		}
+		r.log.WithField("airdrops", airdropIDs(batch)).
+			WithError(err).Error("Failed to handle pending airdrops")
 	}
 
-	return tx, nil
+	return nil, nil
 }
 
-func (r *Runner) genTx(ctx context.Context, gasLimit uint64, airdrop data.Airdrop) ([]byte, error) {
-	tx, err := r.buildTransferTx(airdrop)
-	if err != nil {
-		return nil, fmt.Errorf("build transfer tx: %w", err)
+func (r *Runner) sendAirdropTx(ctx context.Context, batch []data.Airdrop, tx []byte, sequence uint64) (err error) {
+	var (
+		ids      = airdropIDs(batch)
+		txHash   = hashTx(tx)
+		inFlight bool
+	)
+
+	defer func() {
+		// when the tx might have reached the node, the airdrops are kept in
+		// processing status to be settled by reconciliation
+		if err != nil && !inFlight {
+			r.failAirdrops(ctx, batch, err)
+		}
+	}()
+
+	// The status must be persisted before broadcasting, otherwise the airdrop
+	// can be sent twice, if the service is restarted before the update. The
+	// sequence allows to tell whether the tx absent on chain can still be
+	// included, see reconcile.
+	claimed := r.claimAirdrops(ctx, ids, map[string]any{
+		"status":                data.TxStatusProcessing,
+		"tx_hash":               txHash,
+		"tx_sequence":           sequence,
+		"tx_sequence_passed_at": nil,
+		"sender":                r.sender.Address,
+	})
+	if err = ctx.Err(); err != nil {
+		// the status was not necessarily updated, but the tx was not sent
+		return fmt.Errorf("set processing status: %w", err)
+	}
+	if len(claimed) < len(batch) {
+		// some airdrops were cancelled by admin meanwhile, the rest is sent
+		// on the next run
+		r.log.WithField("airdrops", ids).Warn("Airdrops status has changed before broadcasting, skipping the tx")
+		if len(claimed) > 0 {
+			r.updateAirdrops(ctx, airdropIDs(claimed), map[string]any{
+				"status":      data.TxStatusPending,
+				"tx_hash":     nil,
+				"tx_sequence": nil,
+				"sender":      nil,
+			})
+		}
+		return nil
 	}
 
-	builder, err := r.TxConfig.WrapTxBuilder(tx)
+	resp, err := r.broadcastTx(ctx, tx)
 	if err != nil {
-		return nil, fmt.Errorf("wrap tx with builder: %w", err)
+		if inFlight = resp == nil; inFlight {
+			// it is unknown whether the sequence was consumed
+			r.seq.reset()
+		}
+		return fmt.Errorf("broadcast tx: %w", err)
 	}
-	builder.SetGasLimit(gasLimit)
-	// there are no fees on the mainnet now, and applying fees requires a lot of work
-	builder.SetFeeAmount(types.Coins{types.NewInt64Coin("urmo", 0)})
 
-	resp, err := r.Auth.Account(ctx, &authtypes.QueryAccountRequest{Address: r.SenderAddress})
+	r.seq.increment()
+	r.updateAirdropsStatus(ctx, ids, txHash, data.TxStatusSubmitted)
+	return nil
+}
+
+func (r *Runner) createAirdropTx(ctx context.Context, batch []data.Airdrop, account senderAccount) ([]byte, error) {
+	tx, err := r.genTx(ctx, 0, r.zeroFee(), batch, account)
 	if err != nil {
-		return nil, fmt.Errorf("get sender account: %w", err)
+		return nil, fmt.Errorf("failed to generate tx: %w", err)
 	}
 
-	var account ethermint.EthAccount
-	if err = account.Unmarshal(resp.Account.Value); err != nil {
-		return nil, fmt.Errorf("unmarshal sender account: %w", err)
+	gasUsed, err := r.simulateTx(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate tx: %w", err)
 	}
 
-	err = builder.SetSignatures(signing.SignatureV2{
-		PubKey: r.Sender.PubKey(),
-		Data: &signing.SingleSignatureData{
-			SignMode:  r.TxConfig.SignModeHandler().DefaultMode(),
-			Signature: nil,
-		},
-		Sequence: account.Sequence,
-	})
+	gasLimit := uint64(math.Ceil(float64(gasUsed) * r.GasAdjustment))
+	fee, err := r.computeFee(gasLimit)
 	if err != nil {
-		return nil, fmt.Errorf("set signatures to tx: %w", err)
+		return nil, fmt.Errorf("failed to compute fee: %w", err)
 	}
 
-	signerData := xauthsigning.SignerData{
-		ChainID:       r.ChainID,
-		AccountNumber: account.AccountNumber,
-		Sequence:      account.Sequence,
-	}
-	sigV2, err := clienttx.SignWithPrivKey(
-		r.TxConfig.SignModeHandler().DefaultMode(), signerData,
-		builder, r.Sender, r.TxConfig, account.Sequence,
-	)
+	tx, err = r.genTx(ctx, gasLimit, fee, batch, account)
 	if err != nil {
-		return nil, fmt.Errorf("sign with private key: %w", err)
+		return nil, fmt.Errorf("failed to generate tx after simulation: %w", err)
 	}
 
-	if err = builder.SetSignatures(sigV2); err != nil {
-		return nil, fmt.Errorf("set signatures V2: %w", err)
-	}
+	return tx, nil
+}
 
-	return r.TxConfig.TxEncoder()(builder.GetTx())
+// If we don't update tx status from processing, having the successful funds
+// transfer, the airdrop will be settled only by reconciliation. The update is
+// retried until success or context cancellation.
+func (r *Runner) updateAirdropsStatus(ctx context.Context, ids []string, txHash, status string) {
+	r.updateAirdrops(ctx, ids, map[string]any{
+		"status":  status,
+		"tx_hash": nullString(txHash),
+	})
 }
 
-func (r *Runner) simulateTx(ctx context.Context, tx []byte) (gasUsed uint64, err error) {
-	sim, err := r.TxClient.Simulate(ctx, &client.SimulateRequest{TxBytes: tx})
-	if err != nil {
-		return 0, fmt.Errorf("simulate tx: %w", err)
+// settleAirdrops sets the final status of the airdrops from the included tx.
+// Failed airdrops may be retried according to the error.
+func (r *Runner) settleAirdrops(ctx context.Context, airdrops []data.Airdrop, tx *types.TxResponse) {
+	if tx.Code != txCodeSuccess {
+		r.failAirdrops(ctx, airdrops, newTxError(tx))
+		return
 	}
 
-	r.log.Debugf("Gas wanted: %d; gas used in simulation: %d", sim.GasInfo.GasWanted, sim.GasInfo.GasUsed)
-	return sim.GasInfo.GasUsed, nil
+	r.updateAirdrops(ctx, airdropIDs(airdrops), map[string]any{
+		"status":    data.TxStatusCompleted,
+		"tx_hash":   tx.TxHash,
+		"tx_height": tx.Height,
+	})
 }
 
-func (r *Runner) broadcastTx(ctx context.Context, tx []byte) (string, error) {
-	grpcRes, err := r.TxClient.BroadcastTx(ctx, &client.BroadcastTxRequest{
-		Mode:    client.BroadcastMode_BROADCAST_MODE_BLOCK,
-		TxBytes: tx,
-	})
-	if err != nil {
-		return "", fmt.Errorf("send tx: %w", err)
-	}
-	r.log.Debugf("Submitted transaction to the core: %s", grpcRes.TxResponse.TxHash)
+// failAirdrops reschedules the airdrops with exponential backoff on transient
+// errors until the attempts are exhausted, and marks them failed otherwise.
+func (r *Runner) failAirdrops(ctx context.Context, airdrops []data.Airdrop, cause error) {
+	transient := isTransient(cause)
 
-	if grpcRes.TxResponse.Code != txCodeSuccess {
-		return grpcRes.TxResponse.TxHash, fmt.Errorf("got error code: %d, info: %s, log: %s", grpcRes.TxResponse.Code, grpcRes.TxResponse.Info, grpcRes.TxResponse.RawLog)
+	byAttempts := make(map[int][]string)
+	for _, drop := range airdrops {
+		byAttempts[drop.Attempts+1] = append(byAttempts[drop.Attempts+1], drop.ID)
 	}
 
-	return grpcRes.TxResponse.TxHash, nil
-}
+	for attempts, ids := range byAttempts {
+		values := map[string]any{
+			"status":     data.TxStatusFailed,
+			"attempts":   attempts,
+			"last_error": cause.Error(),
+		}
 
-// If we don't update tx status from pending, having the successful funds
-// transfer, it will be possible to double-spend. With this solution the
-// double-spend may still occur, if the service is restarted before the
-// successful update. There is a better solution with file creation on context
-// cancellation and parsing it on start.
-func (r *Runner) updateAirdropStatus(ctx context.Context, id, txHash, status string) {
-	running.UntilSuccess(ctx, r.log, "tx-status-updater", func(_ context.Context) (bool, error) {
-		var ptr *string
-		if txHash != "" {
-			ptr = &txHash
+		if transient && attempts < r.MaxAttempts {
+			backoff := config.RetryBackoff(attempts, r.RetryBackoff, r.MaxRetryBackoff)
+			values["status"] = data.TxStatusPending
+			values["next_attempt_at"] = data.NowAfter(backoff)
+			r.log.WithField("airdrops", ids).Infof("Airdrops will be retried in %s, attempt %d", backoff, attempts)
 		}
 
-		err := r.q.New().Update(id, map[string]any{
-			"status":  status,
-			"tx_hash": ptr,
-		})
+		r.updateAirdrops(ctx, ids, values)
+	}
+}
 
+// updateAirdrops updates all the airdrops at once, because they share the tx
+func (r *Runner) updateAirdrops(ctx context.Context, ids []string, values map[string]any) {
+	running.UntilSuccess(ctx, r.log, "tx-status-updater", func(_ context.Context) (bool, error) {
+		err := r.q.New().UpdateMany(ids, values)
 		return err == nil, err
 	}, 2*time.Second, 10*time.Second)
+	r.publish(ids)
 }
 
-func (r *Runner) buildTransferTx(airdrop data.Airdrop) (types.Tx, error) {
-	tx := &bank.MsgSend{
-		FromAddress: r.SenderAddress,
-		ToAddress:   airdrop.Address,
-		Amount:      r.AirdropCoins,
+// claimAirdrops updates only the airdrops which are still pending, returning
+// them. The update is retried until success or context cancellation.
+func (r *Runner) claimAirdrops(ctx context.Context, ids []string, values map[string]any) []data.Airdrop {
+	var claimed []data.Airdrop
+	running.UntilSuccess(ctx, r.log, "tx-status-updater", func(_ context.Context) (bool, error) {
+		var err error
+		claimed, err = r.q.New().Transition(ids, []string{data.TxStatusPending}, values)
+		return err == nil, err
+	}, 2*time.Second, 10*time.Second)
+	r.publish(airdropIDs(claimed))
+	return claimed
+}
+
+// publish notifies the API in the same process about the status updates
+func (r *Runner) publish(ids []string) {
+	if r.updates != nil && len(ids) > 0 {
+		r.updates.Publish(ids)
 	}
+}
 
-	builder := r.TxConfig.NewTxBuilder()
-	if err := builder.SetMsgs(tx); err != nil {
-		return nil, fmt.Errorf("set messages: %w", err)
+func airdropIDs(airdrops []data.Airdrop) []string {
+	ids := make([]string, len(airdrops))
+	for i, drop := range airdrops {
+		ids[i] = drop.ID
 	}
+	return ids
+}
 
-	return builder.GetTx(), nil
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
 }
@@ -0,0 +1,100 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// txError is the error of the tx execution returned by the chain either on
+// CheckTx or DeliverTx
+type txError struct {
+	Codespace string
+	Code      uint32
+	Log       string
+}
+
+func newTxError(tx *types.TxResponse) *txError {
+	return &txError{
+		Codespace: tx.Codespace,
+		Code:      tx.Code,
+		Log:       tx.RawLog,
+	}
+}
+
+func (e *txError) Error() string {
+	return fmt.Sprintf("tx failed with codespace: %s, code: %d, log: %s", e.Codespace, e.Code, e.Log)
+}
+
+func (e *txError) is(target *sdkerrors.Error) bool {
+	return e.Codespace == target.Codespace() && e.Code == target.ABCICode()
+}
+
+// errSigning wraps the failures of the sender signer, e.g. unavailable or
+// misconfigured remote signer. They don't depend on the airdrops, so the
+// airdrops are left pending and the lane is paused, see Runner.run.
+var errSigning = errors.New("sender signer failed")
+
+// errTxDropped is a transient error, because the tx which sequence was taken
+// by another one can't be included anymore, so the airdrops can be resent
+var errTxDropped = errors.New("tx was dropped, its sequence is taken by another tx")
+
+// transientTxErrors are the chain errors which are expected to disappear on
+// the next attempt. ErrTxInMempoolCache is not here, because it means that
+// the same tx is in flight, see broadcastTx.
+var transientTxErrors = []*sdkerrors.Error{
+	sdkerrors.ErrWrongSequence,
+	sdkerrors.ErrMempoolIsFull,
+	sdkerrors.ErrOutOfGas,
+}
+
+// isTransient reports whether the airdrop should be retried after the error.
+// The unknown errors are considered permanent, so that the airdrop can't be
+// sent multiple times because of unexpected behaviour.
+func isTransient(err error) bool {
+	var txErr *txError
+	if errors.As(err, &txErr) {
+		for _, e := range transientTxErrors {
+			if txErr.is(e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if errors.Is(err, errTxDropped) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+
+	// simulation errors are returned with codes.Unknown and the chain error
+	// in the message
+	msg := err.Error()
+	for _, e := range transientTxErrors {
+		if strings.Contains(msg, e.Error()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSequenceMismatch reports whether the error requires the sender sequence
+// to be resynced with the chain
+func isSequenceMismatch(err error) bool {
+	var txErr *txError
+	if errors.As(err, &txErr) {
+		return txErr.is(sdkerrors.ErrWrongSequence)
+	}
+
+	return strings.Contains(err.Error(), sdkerrors.ErrWrongSequence.Error())
+}
@@ -0,0 +1,82 @@
+package broadcaster
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestTxError(e *sdkerrors.Error) *txError {
+	return &txError{Codespace: e.Codespace(), Code: e.ABCICode(), Log: e.Error()}
+}
+
+func TestIsTransient(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"wrong sequence", newTestTxError(sdkerrors.ErrWrongSequence), true},
+		{"mempool is full", newTestTxError(sdkerrors.ErrMempoolIsFull), true},
+		{"out of gas", newTestTxError(sdkerrors.ErrOutOfGas), true},
+		{"wrapped tx error", fmt.Errorf("broadcast tx: %w", newTestTxError(sdkerrors.ErrWrongSequence)), true},
+		// the same tx is in flight, so the retry would pay twice
+		{"tx in mempool cache", newTestTxError(sdkerrors.ErrTxInMempoolCache), false},
+		{"insufficient funds", newTestTxError(sdkerrors.ErrInsufficientFunds), false},
+		{"same code of another codespace", &txError{Codespace: "bank", Code: sdkerrors.ErrWrongSequence.ABCICode()}, false},
+		{"tx dropped", fmt.Errorf("check tx: %w", errTxDropped), true},
+		{"signer failure", fmt.Errorf("%w: %w", errSigning, errors.New("timeout")), false},
+		{"node unavailable", status.Error(codes.Unavailable, "connection refused"), true},
+		{"deadline exceeded", status.Error(codes.DeadlineExceeded, "deadline exceeded"), true},
+		{"resource exhausted", status.Error(codes.ResourceExhausted, "too many requests"), true},
+		{"aborted", status.Error(codes.Aborted, "aborted"), true},
+		{"tx not found", status.Error(codes.NotFound, "tx not found"), false},
+		{"invalid argument", status.Error(codes.InvalidArgument, "invalid tx"), false},
+		{"simulation sequence mismatch", status.Error(codes.Unknown,
+			fmt.Sprintf("account sequence mismatch, expected 5, got 4: %s", sdkerrors.ErrWrongSequence)), true},
+		{"simulation failure", status.Error(codes.Unknown,
+			fmt.Sprintf("failed to execute message: %s", sdkerrors.ErrInsufficientFunds)), false},
+		{"unknown error", errors.New("unexpected"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isTransient(c.err); got != c.want {
+				t.Fatalf("isTransient(%v) = %t, want %t", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsSequenceMismatch(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"wrong sequence", newTestTxError(sdkerrors.ErrWrongSequence), true},
+		{"wrapped wrong sequence", fmt.Errorf("broadcast tx: %w", newTestTxError(sdkerrors.ErrWrongSequence)), true},
+		{"mempool is full", newTestTxError(sdkerrors.ErrMempoolIsFull), false},
+		// the tx error is matched by code, not by the log
+		{"tx error with sequence log", &txError{
+			Codespace: sdkerrors.ErrOutOfGas.Codespace(),
+			Code:      sdkerrors.ErrOutOfGas.ABCICode(),
+			Log:       sdkerrors.ErrWrongSequence.Error(),
+		}, false},
+		{"simulation sequence mismatch", status.Error(codes.Unknown,
+			fmt.Sprintf("account sequence mismatch, expected 5, got 4: %s", sdkerrors.ErrWrongSequence)), true},
+		{"node unavailable", status.Error(codes.Unavailable, "connection refused"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isSequenceMismatch(c.err); got != c.want {
+				t.Fatalf("isSequenceMismatch(%v) = %t, want %t", c.err, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package broadcaster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gitlab.com/distributed_lab/logan/v3"
+)
+
+const (
+	// leaderLockID is the key of Postgres advisory lock, which is held by the
+	// only broadcaster allowed to send funds among the service replicas
+	leaderLockID int64 = 0x61697264726f70 // "airdrop"
+	// electionInterval is the period of lock acquiring attempts by followers
+	// and the connection health checks by the leader
+	electionInterval = 5 * time.Second
+)
+
+// runAsLeader blocks until the leader lock is acquired and then runs fn with
+// the context, which is cancelled when the lock is lost. The lock is
+// session-level, so it is held on a dedicated connection and is released by
+// Postgres when the process dies. After fn returns due to the lock loss the
+// election is restarted.
+func runAsLeader(ctx context.Context, log *logan.Entry, db *sql.DB, fn func(context.Context)) {
+	for ctx.Err() == nil {
+		conn, err := tryLock(ctx, db)
+		if err != nil {
+			log.WithError(err).Error("Failed to acquire leader lock")
+		}
+		if conn == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(electionInterval):
+			}
+			continue
+		}
+
+		log.Info("Acquired leader lock, broadcasting")
+		lead(ctx, log, conn, fn)
+		log.Info("Released leader lock")
+	}
+}
+
+// tryLock returns the connection holding the lock or nil if the lock is held
+// by another session
+func tryLock(ctx context.Context, db *sql.DB) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get db connection: %w", err)
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockID).Scan(&acquired)
+	if err != nil || !acquired {
+		_ = conn.Close()
+		if err != nil {
+			return nil, fmt.Errorf("try advisory lock: %w", err)
+		}
+		return nil, nil
+	}
+
+	return conn, nil
+}
+
+func lead(ctx context.Context, log *logan.Entry, conn *sql.Conn, fn func(context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(electionInterval)
+	defer ticker.Stop()
+
+	for alive := true; alive; {
+		select {
+		case <-done:
+			alive = false
+		case <-leaderCtx.Done():
+			alive = false
+		case <-ticker.C:
+			if err := conn.PingContext(leaderCtx); err != nil && ctx.Err() == nil {
+				log.WithError(err).Error("Leader lock connection is lost, stopping broadcasting")
+				alive = false
+			}
+		}
+	}
+
+	cancel()
+	<-done
+
+	// the lock is released on session close anyway, unlocking explicitly
+	// allows the connection to be reused by the pool
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockID); err != nil {
+		log.WithError(err).Warn("Failed to release leader lock")
+	}
+	_ = conn.Close()
+}
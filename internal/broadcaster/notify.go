@@ -0,0 +1,82 @@
+package broadcaster
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"gitlab.com/distributed_lab/logan/v3"
+)
+
+const (
+	// pendingChannel is notified by the trigger on each inserted pending airdrop
+	pendingChannel = "airdrops_pending"
+	// busyInterval is the delay before the next run while there is some work
+	// left: in-flight airdrops to reconcile or unselected pending ones
+	busyInterval = 5 * time.Second
+	// errorInterval is the delay before the next run after a failed one
+	errorInterval = 5 * time.Second
+)
+
+// runOnNotify runs fn on each pending airdrop notification, falling back to
+// polling with the given interval, as notifications are lost on reconnects
+// and the retried airdrops are not notified at all. fn reports whether there
+// is some work left, in which case it is run again after a short delay.
+func runOnNotify(ctx context.Context, log *logan.Entry, listener *pq.Listener, pollInterval time.Duration, fn func(context.Context) (bool, error)) {
+	if err := listener.Listen(pendingChannel); err != nil {
+		// polling still works, only the latency is worse
+		log.WithError(err).Error("Failed to listen for pending airdrops")
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				log.Debug("Listener reconnected")
+			}
+			drain(listener.Notify)
+		case <-timer.C:
+		}
+
+		next := pollInterval
+		busy, err := fn(ctx)
+		switch {
+		case err != nil:
+			log.WithError(err).Error("Failed to broadcast airdrops")
+			next = errorInterval
+		case busy:
+			next = busyInterval
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(next)
+	}
+}
+
+// drain skips the notifications accumulated during the run, because a single
+// run handles all of them
+func drain(notify <-chan *pq.Notification) {
+	for {
+		select {
+		case <-notify:
+		default:
+			return
+		}
+	}
+}
+
+func closeListener(log *logan.Entry, listener *pq.Listener) {
+	if err := listener.Close(); err != nil {
+		log.WithError(err).Warn("Failed to close listener")
+	}
+}
@@ -0,0 +1,120 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	client "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"gitlab.com/distributed_lab/logan/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// submitTimeout is the time after which the submitted tx that is still absent
+// on chain is reported as stuck
+const submitTimeout = 5 * time.Minute
+
+// poll checks the submitted airdrop txs and settles the included ones. The
+// least recently updated airdrops are checked first, so the stuck ones can't
+// hold back the rest.
+func (r *Runner) poll(ctx context.Context) error {
+	airdrops, err := r.q.New().
+		FilterByStatus(data.TxStatusSubmitted).
+		OrderByUpdatedAt().
+		Limit(r.QueryLimit).
+		Select()
+	if err != nil {
+		return fmt.Errorf("select submitted airdrops: %w", err)
+	}
+
+	// the committed sequences of the senders are queried once per poll
+	sequences := make(map[string]uint64)
+	for _, group := range groupByTx(airdrops) {
+		if err = r.checkSubmitted(ctx, group, sequences); err != nil {
+			r.log.WithField("airdrops", airdropIDs(group)).
+				WithError(err).Error("Failed to check submitted airdrops")
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) checkSubmitted(ctx context.Context, group []data.Airdrop, sequences map[string]uint64) error {
+	txHash := group[0].TxHash
+	if txHash == nil {
+		return fmt.Errorf("submitted airdrop has no tx hash")
+	}
+
+	// the sequence is queried before the tx, so the tx which is not found
+	// after its sequence was committed can't be included anymore
+	var committed *uint64
+	if sender := group[0].Sender; sender != nil && group[0].TxSequence != nil {
+		sequence, ok := sequences[*sender]
+		if !ok {
+			account, err := queryAccount(ctx, r.Auth, *sender)
+			if err != nil {
+				return fmt.Errorf("query sender account: %w", err)
+			}
+			sequence = account.sequence
+			sequences[*sender] = sequence
+		}
+		committed = &sequence
+	}
+
+	resp, err := r.TxClient.GetTx(ctx, &client.GetTxRequest{Hash: *txHash})
+	if status.Code(err) == codes.NotFound {
+		r.checkNotIncluded(ctx, group, committed)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get tx %s: %w", *txHash, err)
+	}
+
+	r.log.WithField("tx_hash", *txHash).Debugf("Tx was included at height %d with code %d",
+		resp.TxResponse.Height, resp.TxResponse.Code)
+	r.settleAirdrops(ctx, group, resp.TxResponse)
+	return nil
+}
+
+// checkNotIncluded handles the submitted tx which is absent on chain. When the
+// sender committed sequence has passed the tx one and the tx is still absent
+// after DropGracePeriod, the tx is dropped and the airdrops are retried.
+// Otherwise the tx may still be in the mempool, e.g. during the chain halt, so
+// the airdrops are kept submitted, which locks their nullifiers until the
+// outcome is known. The airdrops sent before the tx
+// sequences were stored are quarantined after submitTimeout, because their
+// outcome can't be determined.
+func (r *Runner) checkNotIncluded(ctx context.Context, group []data.Airdrop, committed *uint64) {
+	ids := airdropIDs(group)
+	log := r.log.WithFields(logan.F{
+		"tx_hash":  *group[0].TxHash,
+		"airdrops": ids,
+	})
+
+	sequence := group[0].TxSequence
+	if sequence != nil && committed != nil && *committed > *sequence {
+		if !r.isDropped(group) {
+			return
+		}
+		log.Warn("Submitted tx was dropped")
+		r.failAirdrops(ctx, group, errTxDropped)
+		return
+	}
+
+	if time.Since(group[0].UpdatedAt) <= submitTimeout {
+		return
+	}
+
+	if sequence == nil {
+		log.Warn("Quarantining submitted airdrops with unknown tx sequence")
+		r.updateAirdrops(ctx, ids, map[string]any{
+			"status":     data.TxStatusQuarantined,
+			"last_error": errUnknownSequence.Error(),
+		})
+		return
+	}
+
+	log.Warnf("Submitted tx was not included in %s", submitTimeout)
+}
@@ -0,0 +1,187 @@
+package broadcaster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/cosmos/cosmos-sdk/types"
+	client "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// inFlightTimeout is the time during which the lane waits for the
+	// in-flight airdrop tx to appear on chain before sending the new ones
+	inFlightTimeout = time.Minute
+	// reconcileSearchLimit is the amount of latest txs to the recipient to
+	// look for the airdrop memo
+	reconcileSearchLimit = 100
+)
+
+// errUnknownSequence is the reason to quarantine the in-flight airdrops sent
+// before the tx sequences were stored, as their txs may still be included
+var errUnknownSequence = errors.New("airdrop tx was not found on chain and its sequence is unknown, check the sender txs before retrying")
+
+// reconcile settles the airdrops left in processing status, e.g. after the
+// service restart or a broadcast failure with unknown outcome. The airdrop tx
+// is looked up by the stored hash or, when it is absent, by the memo among the
+// sender transactions to the recipient: found ones are completed or failed
+// according to the tx result. The tx absent on chain is dropped only when the
+// sender committed sequence has passed the tx one and the tx is still absent
+// after DropGracePeriod, then the airdrops are returned to pending. Until then
+// they are kept in processing, because the tx may still be in the mempool or
+// not indexed yet. It returns false when there are airdrops sent less than
+// inFlightTimeout ago, so the new ones must not be sent yet.
+func (r *Runner) reconcile(ctx context.Context) (settled bool, err error) {
+	q := r.q.New().FilterByStatus(data.TxStatusProcessing)
+	if r.primary {
+		q = q.FilterBySenderOrUnassigned(r.sender.Address)
+	} else {
+		q = q.FilterBySender(r.sender.Address)
+	}
+
+	airdrops, err := q.Select()
+	if err != nil {
+		return false, fmt.Errorf("select processing airdrops: %w", err)
+	}
+	if len(airdrops) == 0 {
+		return true, nil
+	}
+
+	// the account is queried before the txs, so the tx which is not found
+	// after its sequence was committed can't be included anymore
+	account, err := queryAccount(ctx, r.Auth, r.sender.Address)
+	if err != nil {
+		return false, fmt.Errorf("query sender account: %w", err)
+	}
+
+	settled = true
+	for _, group := range groupByTx(airdrops) {
+		tx, err := r.findAirdropTx(ctx, group[0])
+		if err != nil {
+			return false, fmt.Errorf("find airdrop tx [id=%s]: %w", group[0].ID, err)
+		}
+
+		var (
+			ids      = airdropIDs(group)
+			sequence = group[0].TxSequence
+		)
+		switch {
+		case tx != nil:
+			r.log.WithField("airdrops", ids).Info("Found in-flight airdrop tx on chain")
+			r.settleAirdrops(ctx, group, tx)
+		case sequence != nil && account.sequence > *sequence:
+			if !r.isDropped(group) {
+				continue
+			}
+			r.log.WithField("airdrops", ids).Info("In-flight airdrop tx was dropped, returning to pending")
+			r.updateAirdrops(ctx, ids, map[string]any{
+				"status":                data.TxStatusPending,
+				"tx_hash":               nil,
+				"tx_sequence":           nil,
+				"tx_sequence_passed_at": nil,
+			})
+		case time.Since(group[0].UpdatedAt) <= inFlightTimeout:
+			settled = false
+		case sequence == nil:
+			r.log.WithField("airdrops", ids).Warn("Quarantining in-flight airdrops with unknown tx sequence")
+			r.updateAirdrops(ctx, ids, map[string]any{
+				"status":     data.TxStatusQuarantined,
+				"last_error": errUnknownSequence.Error(),
+			})
+		default:
+			// the new txs are sent meanwhile: either the in-flight tx is
+			// included before them or one of them takes its sequence
+			r.log.WithField("airdrops", ids).Debugf("In-flight airdrop tx with sequence %d is not found on chain yet", *sequence)
+		}
+	}
+
+	return settled, nil
+}
+
+// isDropped reports whether the tx absent on chain after the sender committed
+// sequence has passed its one stays absent longer than DropGracePeriod. The
+// included tx may be missing for a while, because the txs are indexed
+// asynchronously and the account may be queried from a newer node than the
+// tx, so the first observation only starts the grace period.
+func (r *Runner) isDropped(group []data.Airdrop) bool {
+	passedAt := group[0].TxSequencePassedAt
+	if passedAt != nil {
+		return time.Since(*passedAt) > r.DropGracePeriod
+	}
+
+	ids := airdropIDs(group)
+	r.log.WithField("airdrops", ids).Infof("Sequence of the airdrop tx absent on chain was committed, waiting %s for the tx", r.DropGracePeriod)
+	err := r.q.New().UpdateMany(ids, map[string]any{
+		"tx_sequence_passed_at": squirrel.Expr("NOW()"),
+	})
+	if err != nil {
+		r.log.WithField("airdrops", ids).WithError(err).Error("Failed to save the time the tx sequence was passed")
+	}
+
+	return false
+}
+
+func (r *Runner) findAirdropTx(ctx context.Context, airdrop data.Airdrop) (*types.TxResponse, error) {
+	if airdrop.TxHash != nil {
+		resp, err := r.TxClient.GetTx(ctx, &client.GetTxRequest{Hash: *airdrop.TxHash})
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get tx %s: %w", *airdrop.TxHash, err)
+		}
+		return resp.TxResponse, nil
+	}
+
+	resp, err := r.TxClient.GetTxsEvent(ctx, &client.GetTxsEventRequest{
+		Events: []string{
+			fmt.Sprintf("message.sender='%s'", r.sender.Address),
+			fmt.Sprintf("transfer.recipient='%s'", airdrop.Address),
+		},
+		OrderBy: client.OrderBy_ORDER_BY_DESC,
+		Limit:   reconcileSearchLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search txs by events: %w", err)
+	}
+
+	for i, tx := range resp.Txs {
+		if tx.Body != nil && tx.Body.Memo == airdrop.ID && i < len(resp.TxResponses) {
+			return resp.TxResponses[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// groupByTx groups the airdrops sent in the same tx. Airdrops without tx hash
+// are put to separate groups.
+func groupByTx(airdrops []data.Airdrop) [][]data.Airdrop {
+	var (
+		groups [][]data.Airdrop
+		byHash = make(map[string]int)
+	)
+
+	for _, drop := range airdrops {
+		if drop.TxHash == nil {
+			groups = append(groups, []data.Airdrop{drop})
+			continue
+		}
+
+		i, ok := byHash[*drop.TxHash]
+		if !ok {
+			byHash[*drop.TxHash] = len(groups)
+			groups = append(groups, []data.Airdrop{drop})
+			continue
+		}
+		groups[i] = append(groups[i], drop)
+	}
+
+	return groups
+}
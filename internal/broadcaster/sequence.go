@@ -0,0 +1,92 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	ethermint "github.com/rarimo/rarimo-core/ethermint/types"
+)
+
+// senderAccount is the sender account state used for signing
+type senderAccount struct {
+	number   uint64
+	sequence uint64
+}
+
+// sequenceManager caches the sender account number and sequence. The chain
+// returns the committed sequence, which does not account the txs in the
+// mempool, so the sequence is incremented locally on each successful
+// submission. This allows to send several txs per block. On sequence mismatch
+// the manager must be reset to resync with the chain.
+type sequenceManager struct {
+	auth    authtypes.QueryClient
+	address string
+
+	mu      sync.Mutex
+	synced  bool
+	account senderAccount
+}
+
+func newSequenceManager(auth authtypes.QueryClient, address string) *sequenceManager {
+	return &sequenceManager{
+		auth:    auth,
+		address: address,
+	}
+}
+
+// current returns the account state to sign the next tx with, querying the
+// chain if the state is not synced
+func (m *sequenceManager) current(ctx context.Context) (senderAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.synced {
+		return m.account, nil
+	}
+
+	account, err := queryAccount(ctx, m.auth, m.address)
+	if err != nil {
+		return senderAccount{}, err
+	}
+
+	m.account = account
+	m.synced = true
+
+	return m.account, nil
+}
+
+// queryAccount returns the committed state of the account, which sequence is
+// the one of the next tx to be included in a block
+func queryAccount(ctx context.Context, auth authtypes.QueryClient, address string) (senderAccount, error) {
+	resp, err := auth.Account(ctx, &authtypes.QueryAccountRequest{Address: address})
+	if err != nil {
+		return senderAccount{}, fmt.Errorf("query account: %w", err)
+	}
+
+	var account ethermint.EthAccount
+	if err = account.Unmarshal(resp.Account.Value); err != nil {
+		return senderAccount{}, fmt.Errorf("unmarshal sender account: %w", err)
+	}
+
+	return senderAccount{
+		number:   account.AccountNumber,
+		sequence: account.Sequence,
+	}, nil
+}
+
+// increment must be called when the tx passed CheckTx, so the sequence is
+// consumed
+func (m *sequenceManager) increment() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.account.sequence++
+}
+
+// reset forces the resync with the chain on the next call of current
+func (m *sequenceManager) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+}
@@ -0,0 +1,146 @@
+package broadcaster
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/rarimo/airdrop-svc/internal/data"
+)
+
+// availableBalance returns the sender balance minus the amounts of in-flight
+// airdrops, because the balance on chain does not account the txs in the
+// mempool
+func (r *Runner) availableBalance(ctx context.Context) (types.Coins, error) {
+	resp, err := r.Bank.AllBalances(ctx, &bank.QueryAllBalancesRequest{Address: r.sender.Address})
+	if err != nil {
+		return nil, fmt.Errorf("query sender balances: %w", err)
+	}
+
+	inFlight, err := r.q.New().
+		FilterByStatus(data.TxStatusProcessing, data.TxStatusSubmitted).
+		FilterBySender(r.sender.Address).
+		Select()
+	if err != nil {
+		return nil, fmt.Errorf("select in-flight airdrops: %w", err)
+	}
+
+	balance := resp.Balances
+	for _, drop := range inFlight {
+		amount, err := parseAmount(drop.Amount)
+		if err != nil {
+			continue
+		}
+		balance = deduct(balance, amount)
+	}
+
+	return balance, nil
+}
+
+// saveBalance stores the balance to be exposed by API and warns when it is low
+func (r *Runner) saveBalance(balance types.Coins) {
+	claims := r.fundableClaims(balance)
+
+	err := r.balancesQ.New().Upsert(data.Balance{
+		Address:        r.sender.Address,
+		Amount:         balance.String(),
+		FundableClaims: claims,
+	})
+	if err != nil {
+		r.log.WithError(err).Error("Failed to save sender balance")
+	}
+
+	if claims < r.LowBalanceClaims {
+		r.log.WithField("balance", balance.String()).
+			Warnf("Sender balance is low: %d claims can be funded", claims)
+	}
+}
+
+// fundableClaims returns the amount of airdrops of the configured amount that
+// can be paid with the balance, fees are not accounted
+func (r *Runner) fundableClaims(balance types.Coins) int64 {
+	if r.AirdropCoins.Empty() {
+		return 0
+	}
+
+	claims := int64(-1)
+	for _, coin := range r.AirdropCoins {
+		n := balance.AmountOf(coin.Denom).Quo(coin.Amount)
+		if !n.IsInt64() {
+			continue
+		}
+		if claims < 0 || n.Int64() < claims {
+			claims = n.Int64()
+		}
+	}
+
+	return max(claims, 0)
+}
+
+// batchCost returns the amount required to send the batch. The fee is charged
+// unless it is paid by the fee granter.
+func (r *Runner) batchCost(batch []data.Airdrop) types.Coins {
+	cost := types.NewCoins()
+	if r.FeeGranter == nil {
+		cost = r.estimateFee(len(batch))
+	}
+
+	for _, drop := range batch {
+		// invalid amounts are quarantined before
+		amount, _ := parseAmount(drop.Amount)
+		cost = cost.Add(amount...)
+	}
+	return cost
+}
+
+// estimateFee returns the fee of the expected gas of the airdrops, computed in
+// the same way as the tx fee and limited by the fee cap, if it is configured
+func (r *Runner) estimateFee(airdrops int) types.Coins {
+	gas := uint64(math.Ceil(float64(r.ExpectedGas*uint64(airdrops)) * r.GasAdjustment))
+
+	amount := r.feeAmount(gas)
+	if feeCap := r.MaxFee.AmountOf(r.GasPrice.Denom); r.MaxFee != nil && amount.GT(feeCap) {
+		amount = feeCap
+	}
+
+	return types.NewCoins(types.NewCoin(r.GasPrice.Denom, amount))
+}
+
+// canAffordPending reports whether the budget covers at least one of the
+// pending airdrops, false is returned when there are no pending airdrops. The airdrops with invalid amount are claimed anyway to be
+// quarantined.
+func (r *Runner) canAffordPending(budget types.Coins) (bool, error) {
+	amounts, err := r.q.New().FilterByStatus(data.TxStatusPending).CountByAmount()
+	if err != nil {
+		return false, fmt.Errorf("count pending airdrops by amount: %w", err)
+	}
+
+	for _, a := range amounts {
+		if _, err = parseAmount(a.Amount); err != nil {
+			return true, nil
+		}
+		if budget.IsAllGTE(r.batchCost([]data.Airdrop{{Amount: a.Amount}})) {
+			return true, nil
+		}
+	}
+
+	if len(amounts) > 0 {
+		r.log.WithField("balance", budget.String()).Warn("Sender balance is insufficient, pausing broadcasting")
+	}
+	return false, nil
+}
+
+// deduct subtracts the amount from the balance, leaving out the exhausted
+// denominations
+func deduct(balance, amount types.Coins) types.Coins {
+	res := types.NewCoins()
+	for _, coin := range balance {
+		left := coin.Amount.Sub(amount.AmountOf(coin.Denom))
+		if left.IsPositive() {
+			res = res.Add(types.NewCoin(coin.Denom, left))
+		}
+	}
+	return res
+}
@@ -0,0 +1,195 @@
+package broadcaster
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	sdkclient "github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	client "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/cosmos/cosmos-sdk/types/tx/signing"
+	xauthsigning "github.com/cosmos/cosmos-sdk/x/auth/signing"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/rarimo/airdrop-svc/internal/data"
+)
+
+func (r *Runner) genTx(ctx context.Context, gasLimit uint64, fee types.Coins, batch []data.Airdrop, account senderAccount) ([]byte, error) {
+	tx, err := r.buildTransferTx(batch)
+	if err != nil {
+		return nil, fmt.Errorf("build transfer tx: %w", err)
+	}
+
+	builder, err := r.TxConfig.WrapTxBuilder(tx)
+	if err != nil {
+		return nil, fmt.Errorf("wrap tx with builder: %w", err)
+	}
+	builder.SetGasLimit(gasLimit)
+	builder.SetFeeAmount(fee)
+	if r.FeeGranter != nil {
+		builder.SetFeeGranter(r.FeeGranter)
+	}
+
+	err = builder.SetSignatures(signing.SignatureV2{
+		PubKey: r.sender.Signer.PubKey(),
+		Data: &signing.SingleSignatureData{
+			SignMode:  r.TxConfig.SignModeHandler().DefaultMode(),
+			Signature: nil,
+		},
+		Sequence: account.sequence,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("set signatures to tx: %w", err)
+	}
+
+	signerData := xauthsigning.SignerData{
+		ChainID:       r.ChainID,
+		AccountNumber: account.number,
+		Sequence:      account.sequence,
+	}
+	sigV2, err := r.sign(ctx, signerData, builder)
+	if err != nil {
+		return nil, fmt.Errorf("sign tx: %w", err)
+	}
+
+	if err = builder.SetSignatures(sigV2); err != nil {
+		return nil, fmt.Errorf("set signatures V2: %w", err)
+	}
+
+	return r.TxConfig.TxEncoder()(builder.GetTx())
+}
+
+// sign is the same as clienttx.SignWithPrivKey, but with the sender signer
+func (r *Runner) sign(ctx context.Context, signerData xauthsigning.SignerData, builder sdkclient.TxBuilder) (signing.SignatureV2, error) {
+	signMode := r.TxConfig.SignModeHandler().DefaultMode()
+
+	signBytes, err := r.TxConfig.SignModeHandler().GetSignBytes(signMode, signerData, builder.GetTx())
+	if err != nil {
+		return signing.SignatureV2{}, fmt.Errorf("get sign bytes: %w", err)
+	}
+
+	signature, err := r.sender.Signer.Sign(ctx, signBytes)
+	if err != nil {
+		return signing.SignatureV2{}, fmt.Errorf("%w: %w", errSigning, err)
+	}
+
+	return signing.SignatureV2{
+		PubKey: r.sender.Signer.PubKey(),
+		Data: &signing.SingleSignatureData{
+			SignMode:  signMode,
+			Signature: signature,
+		},
+		Sequence: signerData.Sequence,
+	}, nil
+}
+
+func (r *Runner) simulateTx(ctx context.Context, tx []byte) (gasUsed uint64, err error) {
+	sim, err := r.TxClient.Simulate(ctx, &client.SimulateRequest{TxBytes: tx})
+	if err != nil {
+		return 0, fmt.Errorf("simulate tx: %w", err)
+	}
+
+	r.log.Debugf("Gas wanted: %d; gas used in simulation: %d", sim.GasInfo.GasWanted, sim.GasInfo.GasUsed)
+	return sim.GasInfo.GasUsed, nil
+}
+
+// broadcastTx submits the tx to the mempool. The response is nil on transport
+// errors, when it is unknown whether the tx was accepted.
+func (r *Runner) broadcastTx(ctx context.Context, tx []byte) (*types.TxResponse, error) {
+	grpcRes, err := r.TxClient.BroadcastTx(ctx, &client.BroadcastTxRequest{
+		Mode:    client.BroadcastMode_BROADCAST_MODE_SYNC,
+		TxBytes: tx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("send tx: %w", err)
+	}
+	r.log.Debugf("Submitted transaction to the mempool: %s", grpcRes.TxResponse.TxHash)
+
+	if grpcRes.TxResponse.Code != txCodeSuccess {
+		txErr := newTxError(grpcRes.TxResponse)
+		if txErr.is(sdkerrors.ErrTxInMempoolCache) {
+			// the same tx is already in the mempool, e.g. it was accepted
+			// before the transport error, so it is in flight as well
+			r.log.WithField("tx_hash", grpcRes.TxResponse.TxHash).Warn("Tx is already in the mempool")
+			return grpcRes.TxResponse, nil
+		}
+		return grpcRes.TxResponse, fmt.Errorf("check tx: %w", txErr)
+	}
+
+	return grpcRes.TxResponse, nil
+}
+
+func (r *Runner) buildTransferTx(batch []data.Airdrop) (types.Tx, error) {
+	msgs := make([]types.Msg, len(batch))
+	for i, airdrop := range batch {
+		amount, err := parseAmount(airdrop.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("parse amount of airdrop %s: %w", airdrop.ID, err)
+		}
+
+		msgs[i] = &bank.MsgSend{
+			FromAddress: r.sender.Address,
+			ToAddress:   airdrop.Address,
+			Amount:      amount,
+		}
+	}
+
+	builder := r.TxConfig.NewTxBuilder()
+	if err := builder.SetMsgs(msgs...); err != nil {
+		return nil, fmt.Errorf("set messages: %w", err)
+	}
+	// memo allows to find the tx during reconciliation, when the tx hash was
+	// not stored; for batches the first airdrop is used
+	builder.SetMemo(batch[0].ID)
+
+	return builder.GetTx(), nil
+}
+
+// errFeeCapExceeded is a permanent error, because the gas of the same tx does
+// not change on retries
+var errFeeCapExceeded = errors.New("tx fee exceeds the configured cap")
+
+// zeroFee is used for simulation, when the gas is not known yet
+func (r *Runner) zeroFee() types.Coins {
+	return types.Coins{types.NewInt64Coin(r.GasPrice.Denom, 0)}
+}
+
+// computeFee returns the fee for the gas limit, rounded up to the integer
+// amount
+func (r *Runner) computeFee(gasLimit uint64) (types.Coins, error) {
+	fee := types.Coins{types.NewCoin(r.GasPrice.Denom, r.feeAmount(gasLimit))}
+
+	if r.MaxFee != nil && !fee.IsAllLTE(r.MaxFee) {
+		return nil, fmt.Errorf("%w: fee %s, cap %s", errFeeCapExceeded, fee, r.MaxFee)
+	}
+
+	return fee, nil
+}
+
+func (r *Runner) feeAmount(gasLimit uint64) types.Int {
+	return r.GasPrice.Amount.MulInt(types.NewIntFromUint64(gasLimit)).Ceil().TruncateInt()
+}
+
+// parseAmount parses the amount stored with the airdrop, which must be valid
+// positive coins
+func parseAmount(amount string) (types.Coins, error) {
+	coins, err := types.ParseCoinsNormalized(amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid coins %q: %w", amount, err)
+	}
+	if !coins.IsAllPositive() {
+		return nil, fmt.Errorf("amount %q must be positive", amount)
+	}
+
+	return coins, nil
+}
+
+// hashTx computes the hash of the encoded tx the same way as Tendermint does
+func hashTx(tx []byte) string {
+	hash := sha256.Sum256(tx)
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
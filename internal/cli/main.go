@@ -8,9 +8,11 @@ import (
 	"syscall"
 
 	"github.com/alecthomas/kingpin"
+	"github.com/cosmos/cosmos-sdk/types"
 	"github.com/rarimo/airdrop-svc/internal/broadcaster"
 	"github.com/rarimo/airdrop-svc/internal/config"
 	"github.com/rarimo/airdrop-svc/internal/service"
+	"github.com/rarimo/airdrop-svc/internal/webhooks"
 	"gitlab.com/distributed_lab/kit/kv"
 	"gitlab.com/distributed_lab/logan/v3"
 )
@@ -27,7 +29,9 @@ func Run(args []string) bool {
 		log            = cfg.Log()
 		app            = kingpin.New("airdrop-svc", "")
 		runCmd         = app.Command("run", "run command")
-		serviceCmd     = runCmd.Command("service", "run service")
+		apiCmd         = runCmd.Command("api", "run HTTP API")
+		broadcasterCmd = runCmd.Command("broadcaster", "run airdrops broadcaster and webhooks worker")
+		allCmd         = runCmd.Command("all", "run HTTP API, broadcaster and webhooks worker").Alias("service")
 		migrateCmd     = app.Command("migrate", "migrate command")
 		migrateUpCmd   = migrateCmd.Command("up", "migrate db up")
 		migrateDownCmd = migrateCmd.Command("down", "migrate db down")
@@ -51,10 +55,34 @@ func Run(args []string) bool {
 		}()
 	}
 
+	setBech32Prefixes()
+
+	// SIGHUP terminates the process by default, so it is handled before the
+	// services start: the API reloads the verifier, while the broadcaster has
+	// nothing to reload and ignores it
+	hup := make(chan os.Signal, 1)
+	defer signal.Stop(hup)
+	watchVerifier := func(ctx context.Context, cfg *config.Config) {
+		service.WatchVerifier(ctx, cfg, hup)
+	}
+
+	// the config is loaded lazily, so each command only requires its own
+	// sections, e.g. the API doesn't need the sender keys
 	switch cmd {
-	case serviceCmd.FullCommand():
+	case apiCmd.FullCommand():
+		signal.Notify(hup, syscall.SIGHUP)
+		run(watchVerifier)
 		run(service.Run)
+	case broadcasterCmd.FullCommand():
+		signal.Ignore(syscall.SIGHUP)
 		run(broadcaster.Run)
+		run(webhooks.Run)
+	case allCmd.FullCommand():
+		signal.Notify(hup, syscall.SIGHUP)
+		run(watchVerifier)
+		run(service.Run)
+		run(broadcaster.Run)
+		run(webhooks.Run)
 	case migrateUpCmd.FullCommand():
 		err = MigrateUp(cfg)
 	case migrateDownCmd.FullCommand():
@@ -88,3 +116,11 @@ func Run(args []string) bool {
 
 	return true
 }
+
+func setBech32Prefixes() {
+	c := types.GetConfig()
+	c.SetBech32PrefixForAccount("rarimo", "rarimopub")
+	c.SetBech32PrefixForValidator("rarimovaloper", "rarimovaloperpub")
+	c.SetBech32PrefixForConsensusNode("rarimovalcons", "rarimovalconspub")
+	c.Seal()
+}
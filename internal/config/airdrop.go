@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"gitlab.com/distributed_lab/figure/v3"
+	"gitlab.com/distributed_lab/kit/kv"
+)
+
+// AirdropAmount returns the amount of a single airdrop. It is read apart from
+// the rest of broadcaster config, so that the API doesn't need the sender keys.
+func (c *Config) AirdropAmount() types.Coins {
+	return c.airdrop.Do(func() interface{} {
+		var cfg struct {
+			AirdropAmount string `fig:"airdrop_amount,required"`
+		}
+
+		err := figure.Out(&cfg).From(kv.MustGetStringMap(c.getter, "broadcaster")).Please()
+		if err != nil {
+			panic(fmt.Errorf("failed to figure out airdrop amount: %w", err))
+		}
+
+		amount, err := types.ParseCoinsNormalized(cfg.AirdropAmount)
+		if err != nil {
+			panic(fmt.Errorf("invalid airdrop amount: %w", err))
+		}
+
+		return amount
+	}).(types.Coins)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/rarimo/airdrop-svc/internal/auth"
+	"gitlab.com/distributed_lab/figure/v3"
+	"gitlab.com/distributed_lab/kit/kv"
+)
+
+// Auth returns the authenticator of the privileged endpoints callers. Without
+// the keys and JWT config all the privileged endpoints are forbidden.
+func (c *Config) Auth() *auth.Authenticator {
+	return c.auth.Do(func() interface{} {
+		var cfg struct {
+			APIKeys []struct {
+				Name    string   `fig:"name,required"`
+				KeyHash string   `fig:"key_hash,required"`
+				Scopes  []string `fig:"scopes"`
+			} `fig:"api_keys"`
+			JWT struct {
+				Algorithm string `fig:"algorithm"`
+				KeyPath   string `fig:"key_path"`
+				Issuer    string `fig:"issuer"`
+				Audience  string `fig:"audience"`
+			} `fig:"jwt"`
+		}
+
+		err := figure.Out(&cfg).From(kv.MustGetStringMap(c.getter, "auth")).Please()
+		if err != nil {
+			panic(fmt.Errorf("failed to figure out auth: %w", err))
+		}
+
+		keys := make([]auth.APIKey, len(cfg.APIKeys))
+		for i, key := range cfg.APIKeys {
+			keys[i] = auth.APIKey{
+				Name:    key.Name,
+				KeyHash: key.KeyHash,
+				Scopes:  key.Scopes,
+			}
+		}
+
+		var verifier *auth.JWTVerifier
+		if cfg.JWT.KeyPath != "" {
+			verifier, err = auth.NewJWTVerifier(cfg.JWT.Algorithm, cfg.JWT.KeyPath, cfg.JWT.Issuer, cfg.JWT.Audience)
+			if err != nil {
+				panic(fmt.Errorf("auth: invalid jwt config: %w", err))
+			}
+		}
+
+		authenticator, err := auth.NewAuthenticator(keys, verifier)
+		if err != nil {
+			panic(fmt.Errorf("auth: invalid api keys: %w", err))
+		}
+
+		return authenticator
+	}).(*auth.Authenticator)
+}
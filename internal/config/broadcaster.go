@@ -1,21 +1,24 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	sdkclient "github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
-	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
 	"github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
 	txclient "github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	authtx "github.com/cosmos/cosmos-sdk/x/auth/tx"
 	authtypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/rarimo/airdrop-svc/internal/signer"
 	"gitlab.com/distributed_lab/figure/v3"
 	"gitlab.com/distributed_lab/kit/comfig"
 	"gitlab.com/distributed_lab/kit/kv"
@@ -26,15 +29,51 @@ import (
 
 const accountPrefix = "rarimo"
 
+// Sender is the hot wallet which sends airdrops. Each sender has its own
+// broadcasting lane, because the account sequence must be strictly
+// incrementing.
+type Sender struct {
+	Signer  signer.Signer
+	Address string
+}
+
 type Broadcaster struct {
-	AirdropCoins  types.Coins
-	Sender        cryptotypes.PrivKey
-	SenderAddress string
-	ChainID       string
-	TxConfig      sdkclient.TxConfig
-	TxClient      txclient.ServiceClient
-	Auth          authtypes.QueryClient
-	QueryLimit    uint64
+	AirdropCoins types.Coins
+	Senders      []Sender
+	ChainID      string
+	TxConfig     sdkclient.TxConfig
+	TxClient     txclient.ServiceClient
+	Auth         authtypes.QueryClient
+	Bank         bank.QueryClient
+	QueryLimit   uint64
+	BatchSize    uint64
+	// MaxAttempts is the amount of attempts to send the airdrop on transient
+	// errors before marking it as failed
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// GasPrice is the price of a gas unit, the fee is computed from the gas
+	// limit, which is the simulated gas multiplied by GasAdjustment
+	GasPrice      types.DecCoin
+	GasAdjustment float64
+	// MaxFee is the cap of the tx fee, nil means no cap
+	MaxFee types.Coins
+	// ExpectedGas is the simulated gas of a single airdrop, which is used to
+	// estimate the fee of a batch before the simulation
+	ExpectedGas uint64
+	// FeeGranter is the optional account which pays the fees, nil if the
+	// sender pays
+	FeeGranter types.AccAddress
+	// LowBalanceClaims is the amount of fundable claims below which the
+	// sender balance is considered low
+	LowBalanceClaims int64
+	// PollInterval is the period of the fallback polling for pending
+	// airdrops, which are normally picked up on notification
+	PollInterval time.Duration
+	// DropGracePeriod is the time during which the tx must stay absent on
+	// chain after its sequence was committed to be considered dropped. It
+	// covers the lag of the tx indexer and of the load-balanced nodes.
+	DropGracePeriod time.Duration
 }
 
 type Broadcasterer interface {
@@ -55,11 +94,36 @@ func NewBroadcaster(getter kv.Getter) Broadcasterer {
 func (b *broadcasterer) Broadcaster() Broadcaster {
 	return b.once.Do(func() interface{} {
 		var cfg struct {
-			AirdropAmount    string `fig:"airdrop_amount,required"`
-			CosmosRPC        string `fig:"cosmos_rpc,required"`
-			ChainID          string `fig:"chain_id,required"`
-			SenderPrivateKey string `fig:"sender_private_key,required"`
-			QueryLimit       uint64 `fig:"query_limit"`
+			AirdropAmount     string   `fig:"airdrop_amount,required"`
+			CosmosRPC         string   `fig:"cosmos_rpc,required"`
+			ChainID           string   `fig:"chain_id,required"`
+			SenderPrivateKey  string   `fig:"sender_private_key"`
+			SenderPrivateKeys []string `fig:"sender_private_keys"`
+			SenderKeystores   []struct {
+				Path string `fig:"path,required"`
+				// the passphrase is read from the environment variable
+				PassphraseEnv string `fig:"passphrase_env,required"`
+			} `fig:"sender_keystores"`
+			SenderRemoteSigners []struct {
+				URL     string        `fig:"url,required"`
+				PubKey  string        `fig:"pub_key,required"`
+				Timeout time.Duration `fig:"timeout"`
+			} `fig:"sender_remote_signers"`
+			QueryLimit uint64 `fig:"query_limit"`
+			BatchSize  uint64 `fig:"batch_size"`
+			Retry      struct {
+				MaxAttempts int           `fig:"max_attempts"`
+				Backoff     time.Duration `fig:"backoff"`
+				MaxBackoff  time.Duration `fig:"max_backoff"`
+			} `fig:"retry"`
+			GasPrice      string        `fig:"gas_price"`
+			GasAdjustment float64       `fig:"gas_adjustment"`
+			MaxFee        string        `fig:"max_fee"`
+			ExpectedGas   uint64        `fig:"expected_gas"`
+			FeeGranter    string        `fig:"fee_granter"`
+			LowBalance    int64         `fig:"low_balance_claims"`
+			PollInterval  time.Duration `fig:"poll_interval"`
+			DropGrace     time.Duration `fig:"drop_grace_period"`
 		}
 
 		err := figure.Out(&cfg).From(kv.MustGetStringMap(b.getter, "broadcaster")).Please()
@@ -84,15 +148,60 @@ func (b *broadcasterer) Broadcaster() Broadcaster {
 			panic(fmt.Errorf("broadcaster: failed to dial cosmos core rpc: %w", err))
 		}
 
-		privateKey, err := hexutil.Decode(cfg.SenderPrivateKey)
-		if err != nil {
-			panic(fmt.Errorf("broadcaster: sender private key is not a hex string: %w", err))
+		keys := cfg.SenderPrivateKeys
+		if cfg.SenderPrivateKey != "" {
+			keys = append([]string{cfg.SenderPrivateKey}, keys...)
 		}
 
-		sender := &secp256k1.PrivKey{Key: privateKey}
-		address, err := bech32.ConvertAndEncode(accountPrefix, sender.PubKey().Address().Bytes())
-		if err != nil {
-			panic(fmt.Errorf("failed to convert and encode sender address: %w", err))
+		var signers []signer.Signer
+		for i, key := range keys {
+			privateKey, err := hexutil.Decode(key)
+			if err != nil {
+				panic(fmt.Errorf("broadcaster: sender private key #%d is not a hex string: %w", i, err))
+			}
+			signers = append(signers, signer.FromPrivKey(&secp256k1.PrivKey{Key: privateKey}))
+		}
+
+		for _, ks := range cfg.SenderKeystores {
+			passphrase, ok := os.LookupEnv(ks.PassphraseEnv)
+			if !ok {
+				panic(fmt.Errorf("broadcaster: keystore passphrase env %s is not set", ks.PassphraseEnv))
+			}
+
+			s, err := signer.FromKeystore(ks.Path, passphrase)
+			if err != nil {
+				panic(fmt.Errorf("broadcaster: failed to open keystore %s: %w", ks.Path, err))
+			}
+			signers = append(signers, s)
+		}
+
+		for _, rs := range cfg.SenderRemoteSigners {
+			timeout := 10 * time.Second
+			if rs.Timeout > 0 {
+				timeout = rs.Timeout
+			}
+
+			s, err := signer.NewRemote(rs.URL, rs.PubKey, timeout)
+			if err != nil {
+				panic(fmt.Errorf("broadcaster: invalid remote signer %s: %w", rs.URL, err))
+			}
+			signers = append(signers, s)
+		}
+
+		if len(signers) == 0 {
+			panic(errors.New("broadcaster: at least one sender key, keystore or remote signer must be set"))
+		}
+
+		senders := make([]Sender, len(signers))
+		unique := make(map[string]struct{}, len(signers))
+		for i, s := range signers {
+			if senders[i], err = newSender(s); err != nil {
+				panic(fmt.Errorf("broadcaster: invalid sender #%d: %w", i, err))
+			}
+			if _, ok := unique[senders[i].Address]; ok {
+				panic(fmt.Errorf("broadcaster: duplicated sender %s", senders[i].Address))
+			}
+			unique[senders[i].Address] = struct{}{}
 		}
 
 		queryLimit := uint64(100)
@@ -100,18 +209,116 @@ func (b *broadcasterer) Broadcaster() Broadcaster {
 			queryLimit = cfg.QueryLimit
 		}
 
+		batchSize := uint64(10)
+		if cfg.BatchSize > 0 {
+			batchSize = cfg.BatchSize
+		}
+
+		retry := cfg.Retry
+		if retry.MaxAttempts <= 0 {
+			retry.MaxAttempts = 5
+		}
+		if retry.Backoff <= 0 {
+			retry.Backoff = 30 * time.Second
+		}
+		if retry.MaxBackoff < retry.Backoff {
+			retry.MaxBackoff = max(time.Hour, retry.Backoff)
+		}
+
+		// there are no fees on the mainnet now, so zero price is the default
+		if cfg.GasPrice == "" {
+			cfg.GasPrice = "0urmo"
+		}
+		gasPrice, err := types.ParseDecCoin(cfg.GasPrice)
+		if err != nil {
+			panic(fmt.Errorf("broadcaster: invalid gas price: %w", err))
+		}
+
+		gasAdjustment := float64(3)
+		if cfg.GasAdjustment > 0 {
+			gasAdjustment = cfg.GasAdjustment
+		}
+
+		var maxFee types.Coins
+		if cfg.MaxFee != "" {
+			if maxFee, err = types.ParseCoinsNormalized(cfg.MaxFee); err != nil {
+				panic(fmt.Errorf("broadcaster: invalid max fee: %w", err))
+			}
+		}
+
+		expectedGas := uint64(100000)
+		if cfg.ExpectedGas > 0 {
+			expectedGas = cfg.ExpectedGas
+		}
+
+		var feeGranter types.AccAddress
+		if cfg.FeeGranter != "" {
+			if feeGranter, err = decodeAddress(cfg.FeeGranter); err != nil {
+				panic(fmt.Errorf("broadcaster: invalid fee granter: %w", err))
+			}
+		}
+
+		pollInterval := time.Minute
+		if cfg.PollInterval > 0 {
+			pollInterval = cfg.PollInterval
+		}
+
+		dropGracePeriod := 10 * time.Minute
+		if cfg.DropGrace > 0 {
+			dropGracePeriod = cfg.DropGrace
+		}
+
 		return Broadcaster{
-			Sender:        sender,
-			SenderAddress: address,
-			ChainID:       cfg.ChainID,
+			Senders: senders,
+			ChainID: cfg.ChainID,
 			TxConfig: authtx.NewTxConfig(
 				codec.NewProtoCodec(codectypes.NewInterfaceRegistry()),
 				[]signing.SignMode{signing.SignMode_SIGN_MODE_DIRECT},
 			),
 			TxClient:     txclient.NewServiceClient(cosmosRPC),
 			Auth:         authtypes.NewQueryClient(cosmosRPC),
+			Bank:         bank.NewQueryClient(cosmosRPC),
 			AirdropCoins: amount,
 			QueryLimit:   queryLimit,
+			BatchSize:    batchSize,
+
+			MaxAttempts:     retry.MaxAttempts,
+			RetryBackoff:    retry.Backoff,
+			MaxRetryBackoff: retry.MaxBackoff,
+
+			GasPrice:      gasPrice,
+			GasAdjustment: gasAdjustment,
+			MaxFee:        maxFee,
+			ExpectedGas:   expectedGas,
+			FeeGranter:    feeGranter,
+
+			LowBalanceClaims: cfg.LowBalance,
+			PollInterval:     pollInterval,
+			DropGracePeriod:  dropGracePeriod,
 		}
 	}).(Broadcaster)
 }
+
+func newSender(s signer.Signer) (Sender, error) {
+	address, err := bech32.ConvertAndEncode(accountPrefix, s.PubKey().Address().Bytes())
+	if err != nil {
+		return Sender{}, fmt.Errorf("failed to convert and encode sender address: %w", err)
+	}
+
+	return Sender{
+		Signer:  s,
+		Address: address,
+	}, nil
+}
+
+func decodeAddress(addr string) (types.AccAddress, error) {
+	prefix, bz, err := bech32.DecodeAndConvert(addr)
+	if err != nil {
+		return nil, fmt.Errorf("decode bech32 address: %w", err)
+	}
+	if prefix != accountPrefix {
+		return nil, fmt.Errorf("invalid address prefix %q, expected %q", prefix, accountPrefix)
+	}
+
+	return bz, nil
+}
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/pubsub"
 	"github.com/rarimo/zkverifier-kit/identity"
 	"gitlab.com/distributed_lab/kit/comfig"
 	"gitlab.com/distributed_lab/kit/kv"
@@ -16,7 +20,14 @@ type Config struct {
 
 	airdrop  comfig.Once
 	verifier comfig.Once
+	auth     comfig.Once
+	stats    comfig.Once
+	streams  comfig.Once
+	webhooks comfig.Once
 	getter   kv.Getter
+	updates  *pubsub.Hub
+	// verifierer is the current verifier, which is swapped on reload
+	verifierer atomic.Pointer[Verifierer]
 }
 
 func New(getter kv.Getter) *Config {
@@ -27,5 +38,21 @@ func New(getter kv.Getter) *Config {
 		Logger:           comfig.NewLogger(getter, comfig.LoggerOpts{}),
 		VerifierProvider: identity.NewVerifierProvider(getter),
 		Broadcasterer:    NewBroadcaster(getter),
+		updates:          pubsub.NewHub(),
+	}
+}
+
+// AirdropUpdates returns the hub of airdrop updates, which is shared by the
+// broadcaster and the API running in the same process
+func (c *Config) AirdropUpdates() *pubsub.Hub {
+	return c.updates
+}
+
+// RetryBackoff returns the delay before the retry attempt, which starts from
+// backoff and is doubled on each attempt up to maxBackoff
+func RetryBackoff(attempts int, backoff, maxBackoff time.Duration) time.Duration {
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
 	}
+	return min(backoff, maxBackoff)
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	cases := []struct {
+		name       string
+		attempts   int
+		backoff    time.Duration
+		maxBackoff time.Duration
+		want       time.Duration
+	}{
+		{"no attempts", 0, 30 * time.Second, time.Hour, 30 * time.Second},
+		{"first attempt", 1, 30 * time.Second, time.Hour, 30 * time.Second},
+		{"second attempt", 2, 30 * time.Second, time.Hour, time.Minute},
+		{"fourth attempt", 4, 30 * time.Second, time.Hour, 4 * time.Minute},
+		{"capped", 8, 30 * time.Second, time.Hour, time.Hour},
+		{"many attempts", 1000, 30 * time.Second, time.Hour, time.Hour},
+		{"backoff above max", 1, 2 * time.Hour, time.Hour, time.Hour},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := RetryBackoff(c.attempts, c.backoff, c.maxBackoff); got != c.want {
+				t.Fatalf("RetryBackoff(%d, %s, %s) = %s, want %s",
+					c.attempts, c.backoff, c.maxBackoff, got, c.want)
+			}
+		})
+	}
+}
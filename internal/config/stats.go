@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/distributed_lab/figure/v3"
+	"gitlab.com/distributed_lab/kit/kv"
+)
+
+// StatsCacheInterval returns the period during which the airdrop statistics
+// are served from the cache, because the aggregate queries are heavy
+func (c *Config) StatsCacheInterval() time.Duration {
+	return c.stats.Do(func() interface{} {
+		var cfg struct {
+			CacheInterval time.Duration `fig:"cache_interval"`
+		}
+
+		err := figure.Out(&cfg).From(kv.MustGetStringMap(c.getter, "stats")).Please()
+		if err != nil {
+			panic(fmt.Errorf("failed to figure out stats: %w", err))
+		}
+
+		if cfg.CacheInterval <= 0 {
+			return time.Minute
+		}
+		return cfg.CacheInterval
+	}).(time.Duration)
+}
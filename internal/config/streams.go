@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+
+	"gitlab.com/distributed_lab/figure/v3"
+	"gitlab.com/distributed_lab/kit/kv"
+)
+
+// StreamLimits are the limits of the concurrent airdrop event streams, each
+// of them holds an updates subscription and polls the DB
+type StreamLimits struct {
+	MaxStreams      int
+	MaxPerNullifier int
+}
+
+func (c *Config) StreamLimits() StreamLimits {
+	return c.streams.Do(func() interface{} {
+		var cfg struct {
+			MaxStreams      int `fig:"max_streams"`
+			MaxPerNullifier int `fig:"max_streams_per_nullifier"`
+		}
+
+		err := figure.Out(&cfg).From(kv.MustGetStringMap(c.getter, "streams")).Please()
+		if err != nil {
+			panic(fmt.Errorf("failed to figure out streams: %w", err))
+		}
+
+		limits := StreamLimits{MaxStreams: 1000, MaxPerNullifier: 3}
+		if cfg.MaxStreams > 0 {
+			limits.MaxStreams = cfg.MaxStreams
+		}
+		if cfg.MaxPerNullifier > 0 {
+			limits.MaxPerNullifier = cfg.MaxPerNullifier
+		}
+		return limits
+	}).(StreamLimits)
+}
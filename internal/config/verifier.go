@@ -1,7 +1,10 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 
 	zk "github.com/rarimo/zkverifier-kit"
 	"gitlab.com/distributed_lab/figure/v3"
@@ -12,6 +15,11 @@ type GlobalParams struct {
 	EventID       string
 	QuerySelector string
 	AirdropStart  int64
+	// The rest of the constraints, which are checked by the verifier, so
+	// that the clients can tell whether the user is eligible
+	MinAge           int
+	Citizenships     []string
+	MaxIdentityCount int64
 }
 
 type Verifierer struct {
@@ -19,49 +27,106 @@ type Verifierer struct {
 	ZkVerifier *zk.Verifier
 }
 
+// Verifier returns the current verifier, which is replaced on ReloadVerifier,
+// so it must be obtained for each verification instead of being stored
 func (c *Config) Verifier() *Verifierer {
-	return c.verifier.Do(func() interface{} {
-		var cfg struct {
-			VerificationKeyPath      string   `fig:"verification_key_path,required"`
-			AllowedAge               int      `fig:"allowed_age,required"`
-			AllowedCitizenships      []string `fig:"allowed_citizenships,required"`
-			AllowedQuerySelector     string   `fig:"allowed_query_selector,required"`
-			AllowedEventID           string   `fig:"allowed_event_id,required"`
-			AllowedIdentityCount     int64    `fig:"allowed_identity_count,required"`
-			AllowedIdentityTimestamp int64    `fig:"allowed_identity_timestamp,required"`
-		}
+	if v := c.verifierer.Load(); v != nil {
+		return v
+	}
 
-		err := figure.
-			Out(&cfg).
-			With(figure.BaseHooks).
-			From(kv.MustGetStringMap(c.getter, "verifier")).
-			Please()
+	c.verifier.Do(func() interface{} {
+		v, err := c.newVerifier(c.getter)
 		if err != nil {
-			panic(fmt.Errorf("failed to figure out verifier: %w", err))
+			panic(fmt.Errorf("failed to initialize verifier: %w", err))
 		}
 
-		v, err := zk.NewPassportVerifier(nil,
-			zk.WithVerificationKeyFile(cfg.VerificationKeyPath),
-			zk.WithCitizenships(cfg.AllowedCitizenships...),
-			zk.WithAgeAbove(cfg.AllowedAge),
-			zk.WithProofSelectorValue(cfg.AllowedQuerySelector),
-			zk.WithEventID(cfg.AllowedEventID),
-			zk.WithIdentityVerifier(c.ProvideVerifier()),
-			zk.WithIdentitiesCounter(cfg.AllowedIdentityCount),
-			zk.WithIdentitiesCreationTimestampLimit(cfg.AllowedIdentityTimestamp),
-		)
+		c.verifierer.Store(v)
+		return v
+	})
 
-		if err != nil {
-			panic(fmt.Errorf("failed to initialize passport verifier: %w", err))
-		}
+	return c.verifierer.Load()
+}
 
-		return &Verifierer{
-			ZkVerifier: v,
-			Params: GlobalParams{
-				AirdropStart:  cfg.AllowedIdentityTimestamp,
-				EventID:       cfg.AllowedEventID,
-				QuerySelector: cfg.AllowedQuerySelector,
-			},
-		}
-	}).(*Verifierer)
+// ReloadVerifier rebuilds the verifier from the verifier section of the
+// getter and swaps the current one. The current verifier is kept when the
+// new config is invalid.
+func (c *Config) ReloadVerifier(getter kv.Getter) (*Verifierer, error) {
+	// the initial verifier must be built from the startup config
+	c.Verifier()
+
+	v, err := c.newVerifier(getter)
+	if err != nil {
+		return nil, err
+	}
+
+	c.verifierer.Store(v)
+	return v, nil
+}
+
+func (c *Config) newVerifier(getter kv.Getter) (*Verifierer, error) {
+	var cfg struct {
+		VerificationKeyPath      string   `fig:"verification_key_path,required"`
+		AllowedAge               int      `fig:"allowed_age,required"`
+		AllowedCitizenships      []string `fig:"allowed_citizenships,required"`
+		AllowedQuerySelector     string   `fig:"allowed_query_selector,required"`
+		AllowedEventID           string   `fig:"allowed_event_id,required"`
+		AllowedIdentityCount     int64    `fig:"allowed_identity_count,required"`
+		AllowedIdentityTimestamp int64    `fig:"allowed_identity_timestamp,required"`
+	}
+
+	raw, err := getter.GetStringMap("verifier")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get verifier section: %w", err)
+	}
+
+	err = figure.
+		Out(&cfg).
+		With(figure.BaseHooks).
+		From(raw).
+		Please()
+	if err != nil {
+		return nil, fmt.Errorf("failed to figure out verifier: %w", err)
+	}
+
+	if len(cfg.AllowedCitizenships) == 0 {
+		return nil, errors.New("allowed_citizenships must not be empty")
+	}
+	if cfg.AllowedAge < 0 || cfg.AllowedIdentityCount < 0 || cfg.AllowedIdentityTimestamp < 0 {
+		return nil, errors.New("allowed_age, allowed_identity_count and allowed_identity_timestamp must not be negative")
+	}
+
+	// the key is read here instead of zk.WithVerificationKeyFile, so the
+	// broken key is rejected on reload instead of failing each proof
+	key, err := os.ReadFile(cfg.VerificationKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verification key: %w", err)
+	}
+	if !json.Valid(key) {
+		return nil, fmt.Errorf("verification key %s is not a valid JSON", cfg.VerificationKeyPath)
+	}
+
+	v, err := zk.NewPassportVerifier(key,
+		zk.WithCitizenships(cfg.AllowedCitizenships...),
+		zk.WithAgeAbove(cfg.AllowedAge),
+		zk.WithProofSelectorValue(cfg.AllowedQuerySelector),
+		zk.WithEventID(cfg.AllowedEventID),
+		zk.WithIdentityVerifier(c.ProvideVerifier()),
+		zk.WithIdentitiesCounter(cfg.AllowedIdentityCount),
+		zk.WithIdentitiesCreationTimestampLimit(cfg.AllowedIdentityTimestamp),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize passport verifier: %w", err)
+	}
+
+	return &Verifierer{
+		ZkVerifier: v,
+		Params: GlobalParams{
+			AirdropStart:     cfg.AllowedIdentityTimestamp,
+			EventID:          cfg.AllowedEventID,
+			QuerySelector:    cfg.AllowedQuerySelector,
+			MinAge:           cfg.AllowedAge,
+			Citizenships:     cfg.AllowedCitizenships,
+			MaxIdentityCount: cfg.AllowedIdentityCount,
+		},
+	}, nil
 }
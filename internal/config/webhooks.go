@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"gitlab.com/distributed_lab/figure/v3"
+	"gitlab.com/distributed_lab/kit/kv"
+)
+
+// WebhookSubscription is the partner endpoint notified about the airdrop
+// status changes. The requests are signed with the secret.
+type WebhookSubscription struct {
+	Name   string
+	URL    string
+	Secret []byte
+	// Statuses are the new statuses of the airdrops to notify about, empty
+	// means all
+	Statuses []string
+}
+
+// Accepts reports whether the subscription is notified about the transition
+// to the status
+func (s WebhookSubscription) Accepts(status string) bool {
+	return len(s.Statuses) == 0 || slices.Contains(s.Statuses, status)
+}
+
+type Webhooks struct {
+	Subscriptions []WebhookSubscription
+	// PollInterval is the period of the outbox and the deliveries polling
+	PollInterval time.Duration
+	// Timeout is the limit of a single delivery request
+	Timeout   time.Duration
+	BatchSize uint64
+	// MaxAttempts is the amount of attempts to deliver the event before it is
+	// moved to the dead letters
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Webhooks returns the webhook subscriptions and the delivery settings.
+// Without subscriptions the outbox is still drained.
+func (c *Config) Webhooks() Webhooks {
+	return c.webhooks.Do(func() interface{} {
+		var cfg struct {
+			Subscriptions []struct {
+				Name string `fig:"name,required"`
+				URL  string `fig:"url,required"`
+				// the secret is read from the environment variable
+				SecretEnv string   `fig:"secret_env,required"`
+				Statuses  []string `fig:"statuses"`
+			} `fig:"subscriptions"`
+			PollInterval time.Duration `fig:"poll_interval"`
+			Timeout      time.Duration `fig:"timeout"`
+			BatchSize    uint64        `fig:"batch_size"`
+			Retry        struct {
+				MaxAttempts int           `fig:"max_attempts"`
+				Backoff     time.Duration `fig:"backoff"`
+				MaxBackoff  time.Duration `fig:"max_backoff"`
+			} `fig:"retry"`
+		}
+
+		err := figure.Out(&cfg).From(kv.MustGetStringMap(c.getter, "webhooks")).Please()
+		if err != nil {
+			panic(fmt.Errorf("failed to figure out webhooks: %w", err))
+		}
+
+		subscriptions := make([]WebhookSubscription, len(cfg.Subscriptions))
+		unique := make(map[string]struct{}, len(cfg.Subscriptions))
+		for i, s := range cfg.Subscriptions {
+			if _, ok := unique[s.Name]; ok {
+				panic(fmt.Errorf("webhooks: duplicated subscription %s", s.Name))
+			}
+			unique[s.Name] = struct{}{}
+
+			if err = validateWebhookURL(s.URL); err != nil {
+				panic(fmt.Errorf("webhooks: invalid url of subscription %s: %w", s.Name, err))
+			}
+
+			secret := os.Getenv(s.SecretEnv)
+			if secret == "" {
+				panic(fmt.Errorf("webhooks: secret env %s of subscription %s is not set", s.SecretEnv, s.Name))
+			}
+
+			for _, status := range s.Statuses {
+				if !slices.Contains(airdropStatuses, status) {
+					panic(fmt.Errorf("webhooks: invalid status %q of subscription %s", status, s.Name))
+				}
+			}
+
+			subscriptions[i] = WebhookSubscription{
+				Name:     s.Name,
+				URL:      s.URL,
+				Secret:   []byte(secret),
+				Statuses: s.Statuses,
+			}
+		}
+
+		pollInterval := 5 * time.Second
+		if cfg.PollInterval > 0 {
+			pollInterval = cfg.PollInterval
+		}
+
+		timeout := 10 * time.Second
+		if cfg.Timeout > 0 {
+			timeout = cfg.Timeout
+		}
+
+		batchSize := uint64(100)
+		if cfg.BatchSize > 0 {
+			batchSize = cfg.BatchSize
+		}
+
+		retry := cfg.Retry
+		if retry.MaxAttempts <= 0 {
+			retry.MaxAttempts = 10
+		}
+		if retry.Backoff <= 0 {
+			retry.Backoff = 30 * time.Second
+		}
+		if retry.MaxBackoff < retry.Backoff {
+			retry.MaxBackoff = max(6*time.Hour, retry.Backoff)
+		}
+
+		return Webhooks{
+			Subscriptions: subscriptions,
+			PollInterval:  pollInterval,
+			Timeout:       timeout,
+			BatchSize:     batchSize,
+			MaxAttempts:   retry.MaxAttempts,
+			Backoff:       retry.Backoff,
+			MaxBackoff:    retry.MaxBackoff,
+		}
+	}).(Webhooks)
+}
+
+var airdropStatuses = []string{
+	data.TxStatusPending,
+	data.TxStatusProcessing,
+	data.TxStatusSubmitted,
+	data.TxStatusCompleted,
+	data.TxStatusFailed,
+	data.TxStatusQuarantined,
+	data.TxStatusCancelled,
+}
+
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return errors.New("host is missing")
+	}
+
+	return nil
+}
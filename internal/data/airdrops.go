@@ -11,43 +11,95 @@ import (
 )
 
 const (
-	TxStatusPending   = "pending"
-	TxStatusCompleted = "completed"
-	TxStatusFailed    = "failed"
+	TxStatusPending    = "pending"
+	TxStatusProcessing = "processing"
+	TxStatusSubmitted  = "submitted"
+	TxStatusCompleted  = "completed"
+	TxStatusFailed     = "failed"
+	// TxStatusQuarantined is set for the airdrops that can't be sent without
+	// manual intervention, e.g. with malformed amount
+	TxStatusQuarantined = "quarantined"
+	// TxStatusCancelled is set by admin for the pending airdrops that must not
+	// be sent. The nullifier can't be used for a new claim.
+	TxStatusCancelled = "cancelled"
 )
 
 const airdropsTable = "airdrops"
 
+// nullifierUniqueIndex guarantees that there is at most one pending or
+// completed airdrop per nullifier, see migration 002.
+const nullifierUniqueIndex = "airdrops_nullifier_unique_idx"
+
+// ErrNullifierConflict is returned from Insert when a non-failed airdrop with
+// the same nullifier already exists.
+var ErrNullifierConflict = errors.New("airdrop with this nullifier already exists")
+
 type Airdrop struct {
-	ID        string    `db:"id"`
-	Nullifier string    `db:"nullifier"`
-	Address   string    `db:"address"`
-	TxHash    *string   `db:"tx_hash"`
-	Amount    string    `db:"amount"`
-	Status    string    `db:"status"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID        string  `db:"id"`
+	Nullifier string  `db:"nullifier"`
+	Address   string  `db:"address"`
+	TxHash    *string `db:"tx_hash"`
+	TxHeight  *int64  `db:"tx_height"`
+	// Sender is the address that sent the last airdrop tx
+	Sender *string `db:"sender"`
+	// TxSequence is the sender sequence the last airdrop tx was signed with
+	TxSequence *uint64 `db:"tx_sequence"`
+	// TxSequencePassedAt is the time when the last airdrop tx was first found
+	// absent on chain after the sender committed sequence had passed its one
+	TxSequencePassedAt *time.Time `db:"tx_sequence_passed_at"`
+	Amount             string     `db:"amount"`
+	Status             string     `db:"status"`
+	CreatedAt          time.Time  `db:"created_at"`
+	UpdatedAt          time.Time  `db:"updated_at"`
+	// LastError is the reason of the last failed attempt to send the airdrop
+	LastError     *string   `db:"last_error"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+}
+
+// NowAfter returns the SQL expression of the DB current time shifted by d. It
+// must be used instead of Go time for the values compared with NOW(), because
+// the columns are stored without time zone.
+func NowAfter(d time.Duration) squirrel.Sqlizer {
+	return squirrel.Expr("NOW() + ?::interval", fmt.Sprintf("%d milliseconds", d.Milliseconds()))
 }
 
 type AirdropsQ struct {
 	db       *pgdb.DB
 	selector squirrel.SelectBuilder
+	// actor is recorded in the events of the status transitions along with
+	// the optional identity, e.g. the admin name
+	actor   string
+	actorID *string
 }
 
-func NewAirdropsQ(db *pgdb.DB) *AirdropsQ {
+func NewAirdropsQ(db *pgdb.DB, actor string) *AirdropsQ {
 	return &AirdropsQ{
 		db:       db,
 		selector: squirrel.Select("*").From(airdropsTable),
+		actor:    actor,
 	}
 }
 
 func (q *AirdropsQ) New() *AirdropsQ {
-	return NewAirdropsQ(q.db)
+	res := NewAirdropsQ(q.db, q.actor)
+	res.actorID = q.actorID
+	return res
+}
+
+// WithActor overrides the actor recorded in the events along with its
+// identity
+func (q *AirdropsQ) WithActor(actor, id string) *AirdropsQ {
+	q.actor = actor
+	q.actorID = &id
+	return q
 }
 
+// Insert creates the airdrop along with its creation event, which is also
+// enqueued for webhook delivery
 func (q *AirdropsQ) Insert(p Airdrop) (*Airdrop, error) {
 	var res Airdrop
-	stmt := squirrel.Insert(airdropsTable).SetMap(map[string]interface{}{
+	insert := squirrel.Insert(airdropsTable).SetMap(map[string]interface{}{
 		"nullifier": p.Nullifier,
 		"address":   p.Address,
 		"tx_hash":   p.TxHash,
@@ -55,7 +107,21 @@ func (q *AirdropsQ) Insert(p Airdrop) (*Airdrop, error) {
 		"status":    p.Status,
 	}).Suffix("RETURNING *")
 
+	event := squirrel.Insert(eventsTable).
+		Columns("airdrop_id", "new_status", "tx_hash", "actor", "actor_id").
+		Select(squirrel.Select("id", "status", "tx_hash").
+			Column("?::airdrop_actor_enum", q.actor).
+			Column("?::text", q.actorID).
+			From("inserted")).
+		Suffix("RETURNING id")
+
+	stmt := squirrel.Select("*").From("inserted").
+		PrefixExpr(squirrel.Expr("WITH inserted AS (?), event AS (?), outbox AS (?)", insert, event, outboxInsert()))
+
 	if err := q.db.Get(&res, stmt); err != nil {
+		if pgdb.IsConstraintErr(err, nullifierUniqueIndex) {
+			return nil, ErrNullifierConflict
+		}
 		return nil, fmt.Errorf("insert airdrop %+v: %w", p, err)
 	}
 
@@ -63,10 +129,113 @@ func (q *AirdropsQ) Insert(p Airdrop) (*Airdrop, error) {
 }
 
 func (q *AirdropsQ) Update(id string, values map[string]any) error {
-	stmt := squirrel.Update(airdropsTable).SetMap(values).Where(squirrel.Eq{"id": id})
+	return q.UpdateMany([]string{id}, values)
+}
+
+// UpdateMany updates the airdrops. When the status is set, the transition
+// events are recorded in the same statement, see transition.
+func (q *AirdropsQ) UpdateMany(ids []string, values map[string]any) error {
+	var stmt squirrel.Sqlizer = squirrel.Update(airdropsTable).
+		SetMap(values).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": ids})
+
+	if _, ok := values["status"]; ok {
+		stmt = q.transition(squirrel.Eq{"id": ids}, values)
+	}
+
+	if err := q.db.Exec(stmt); err != nil {
+		return fmt.Errorf("update airdrops [ids=%v values=%v]: %w", ids, values, err)
+	}
+
+	return nil
+}
+
+// Transition updates only the airdrops which have one of the given statuses,
+// recording the events, and returns the updated airdrops. It is used when the
+// concurrent update of the status is possible.
+func (q *AirdropsQ) Transition(ids []string, from []string, values map[string]any) ([]Airdrop, error) {
+	var res []Airdrop
+
+	err := q.db.Select(&res, q.transition(squirrel.Eq{"id": ids, "status": from}, values))
+	if err != nil {
+		if pgdb.IsConstraintErr(err, nullifierUniqueIndex) {
+			return nil, ErrNullifierConflict
+		}
+		return nil, fmt.Errorf("transition airdrops [ids=%v from=%v values=%v]: %w", ids, from, values, err)
+	}
+
+	return res, nil
+}
+
+// transition returns the statement which updates the airdrops matching the
+// condition and records the events with the error from last_error value, if
+// any, returning the updated airdrops. The events are enqueued for webhook
+// delivery in the same statement. The rows are locked to get the old
+// status reliably.
+func (q *AirdropsQ) transition(where squirrel.Sqlizer, values map[string]any) squirrel.Sqlizer {
+	prev := squirrel.Select("id", "status").
+		From(airdropsTable).
+		Where(where).
+		Suffix("FOR UPDATE")
+
+	update := squirrel.Update(airdropsTable).
+		SetMap(values).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		From("prev").
+		Where(airdropsTable + ".id = prev.id").
+		Suffix("RETURNING " + airdropsTable + ".*")
+
+	event := squirrel.Insert(eventsTable).
+		Columns("airdrop_id", "old_status", "new_status", "tx_hash", "error", "actor", "actor_id").
+		Select(squirrel.Select("updated.id", "prev.status", "updated.status", "updated.tx_hash").
+			Column("?::text", values["last_error"]).
+			Column("?::airdrop_actor_enum", q.actor).
+			Column("?::text", q.actorID).
+			From("updated").
+			Join("prev ON prev.id = updated.id")).
+		Suffix("RETURNING id")
+
+	return squirrel.Select("*").From("updated").
+		PrefixExpr(squirrel.Expr("WITH prev AS (?), updated AS (?), event AS (?), outbox AS (?)", prev, update, event, outboxInsert()))
+}
+
+// ClaimPending returns the pending airdrops ready to be sent, hiding them from
+// the concurrent claims until the lease expires. The status is not changed,
+// so the claimed airdrops are still cancellable and the expired claim is
+// taken over by another lane.
+func (q *AirdropsQ) ClaimPending(limit uint64, lease time.Duration) ([]Airdrop, error) {
+	var res []Airdrop
+
+	ready := squirrel.Select("id").
+		From(airdropsTable).
+		Where(squirrel.Eq{"status": TxStatusPending}).
+		Where("next_attempt_at <= NOW()").
+		OrderBy("created_at", "id").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	stmt := squirrel.Update(airdropsTable).
+		Set("next_attempt_at", NowAfter(lease)).
+		Where(squirrel.Expr("id IN (?)", ready)).
+		Suffix("RETURNING *")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("claim pending airdrops: %w", err)
+	}
+
+	return res, nil
+}
+
+// ReleaseClaim makes the claimed airdrops, which are still pending, available
+// to the other lanes immediately
+func (q *AirdropsQ) ReleaseClaim(ids []string) error {
+	stmt := squirrel.Update(airdropsTable).
+		Set("next_attempt_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": ids, "status": TxStatusPending})
 
 	if err := q.db.Exec(stmt); err != nil {
-		return fmt.Errorf("update airdrop status [id=%s values=%v]: %w", id, values, err)
+		return fmt.Errorf("release claimed airdrops [ids=%v]: %w", ids, err)
 	}
 
 	return nil
@@ -110,17 +279,166 @@ func (q *AirdropsQ) Get() (*Airdrop, error) {
 	return &res, nil
 }
 
+// StatusCount is the amount of airdrops with the status
+type StatusCount struct {
+	Status string `db:"status"`
+	Count  int64  `db:"count"`
+}
+
+// AmountCount is the amount of airdrops with the same coins amount
+type AmountCount struct {
+	Amount string `db:"amount"`
+	Count  int64  `db:"count"`
+}
+
+// DailyCount is the amount of airdrops created on the day
+type DailyCount struct {
+	Day   time.Time `db:"day"`
+	Count int64     `db:"count"`
+}
+
+func (q *AirdropsQ) CountByStatus() ([]StatusCount, error) {
+	var res []StatusCount
+	stmt := q.selector.RemoveColumns().Columns("status", "COUNT(*) AS count").GroupBy("status")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("count airdrops by status: %w", err)
+	}
+
+	return res, nil
+}
+
+// CountByAmount groups the airdrops by amount, the total must be computed by
+// the caller, because the amounts are stored as coins strings
+func (q *AirdropsQ) CountByAmount() ([]AmountCount, error) {
+	var res []AmountCount
+	stmt := q.selector.RemoveColumns().Columns("amount", "COUNT(*) AS count").GroupBy("amount")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("count airdrops by amount: %w", err)
+	}
+
+	return res, nil
+}
+
+// CountByDay returns the amount of airdrops created per day in chronological
+// order, the days without airdrops are omitted
+func (q *AirdropsQ) CountByDay() ([]DailyCount, error) {
+	var res []DailyCount
+	stmt := q.selector.RemoveColumns().
+		Columns("date_trunc('day', created_at) AS day", "COUNT(*) AS count").
+		GroupBy("day").
+		OrderBy("day")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("count airdrops by day: %w", err)
+	}
+
+	return res, nil
+}
+
+// MedianCompletionTime returns the median time between the creation of the
+// airdrops and their completed events. Nil is returned when there are no
+// completed airdrops.
+func (q *AirdropsQ) MedianCompletionTime() (*time.Duration, error) {
+	var seconds *float64
+	stmt := q.selector.RemoveColumns().
+		Column("percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM "+
+			eventsTable+".created_at - "+airdropsTable+".created_at))").
+		Join(eventsTable+" ON "+eventsTable+".airdrop_id = "+airdropsTable+".id AND "+
+			eventsTable+".new_status = ?", TxStatusCompleted)
+
+	if err := q.db.Get(&seconds, stmt); err != nil {
+		return nil, fmt.Errorf("get median airdrop completion time: %w", err)
+	}
+	if seconds == nil {
+		return nil, nil
+	}
+
+	age := time.Duration(*seconds * float64(time.Second))
+	return &age, nil
+}
+
 func (q *AirdropsQ) Limit(limit uint64) *AirdropsQ {
 	q.selector = q.selector.Limit(limit)
 	return q
 }
 
+// OrderByUpdatedAt sorts the airdrops from the least recently updated one
+func (q *AirdropsQ) OrderByUpdatedAt() *AirdropsQ {
+	q.selector = q.selector.OrderBy("updated_at", "id")
+	return q
+}
+
 func (q *AirdropsQ) FilterByNullifier(nullifier string) *AirdropsQ {
 	q.selector = q.selector.Where(squirrel.Eq{"nullifier": nullifier})
 	return q
 }
 
-func (q *AirdropsQ) FilterByStatus(status string) *AirdropsQ {
-	q.selector = q.selector.Where(squirrel.Eq{"status": status})
+func (q *AirdropsQ) FilterByStatus(statuses ...string) *AirdropsQ {
+	q.selector = q.selector.Where(squirrel.Eq{"status": statuses})
+	return q
+}
+
+func (q *AirdropsQ) FilterByID(ids ...string) *AirdropsQ {
+	q.selector = q.selector.Where(squirrel.Eq{"id": ids})
+	return q
+}
+
+func (q *AirdropsQ) FilterByAddress(address string) *AirdropsQ {
+	q.selector = q.selector.Where(squirrel.Eq{"address": address})
+	return q
+}
+
+// FilterByCreatedAt leaves the airdrops created in the range, zero bounds are
+// ignored
+func (q *AirdropsQ) FilterByCreatedAt(from, to time.Time) *AirdropsQ {
+	if !from.IsZero() {
+		q.selector = q.selector.Where(squirrel.GtOrEq{"created_at": from.UTC()})
+	}
+	if !to.IsZero() {
+		q.selector = q.selector.Where(squirrel.Lt{"created_at": to.UTC()})
+	}
+	return q
+}
+
+func (q *AirdropsQ) Page(params *pgdb.OffsetPageParams) *AirdropsQ {
+	q.selector = params.ApplyTo(q.selector, "created_at", "id")
+	return q
+}
+
+// PageAfter applies keyset pagination by creation time, the cursor is the ID
+// of the last airdrop from the previous page, empty for the first page
+func (q *AirdropsQ) PageAfter(cursor string, limit uint64, order string) *AirdropsQ {
+	cmp, dir := "<", "desc"
+	if order == pgdb.OrderTypeAsc {
+		cmp, dir = ">", "asc"
+	}
+
+	if cursor != "" {
+		q.selector = q.selector.Where(
+			fmt.Sprintf("(created_at, id) %s (SELECT created_at, id FROM %s WHERE id = ?)", cmp, airdropsTable),
+			cursor,
+		)
+	}
+
+	q.selector = q.selector.
+		OrderBy("created_at "+dir, "id "+dir).
+		Limit(limit)
+	return q
+}
+
+func (q *AirdropsQ) FilterBySender(sender string) *AirdropsQ {
+	q.selector = q.selector.Where(squirrel.Eq{"sender": sender})
+	return q
+}
+
+// FilterBySenderOrUnassigned also includes the airdrops without sender, which
+// were sent before senders were recorded
+func (q *AirdropsQ) FilterBySenderOrUnassigned(sender string) *AirdropsQ {
+	q.selector = q.selector.Where(squirrel.Or{
+		squirrel.Eq{"sender": sender},
+		squirrel.Eq{"sender": nil},
+	})
 	return q
 }
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+const balancesTable = "sender_balances"
+
+// Balance is the last known balance of the airdrop sender, which is available
+// for new airdrops
+type Balance struct {
+	Address string `db:"address"`
+	Amount  string `db:"amount"`
+	// FundableClaims is the amount of airdrops that can be paid with the
+	// balance
+	FundableClaims int64     `db:"fundable_claims"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+type BalancesQ struct {
+	db       *pgdb.DB
+	selector squirrel.SelectBuilder
+}
+
+func NewBalancesQ(db *pgdb.DB) *BalancesQ {
+	return &BalancesQ{
+		db:       db,
+		selector: squirrel.Select("*").From(balancesTable),
+	}
+}
+
+func (q *BalancesQ) New() *BalancesQ {
+	return NewBalancesQ(q.db)
+}
+
+func (q *BalancesQ) Upsert(b Balance) error {
+	stmt := squirrel.Insert(balancesTable).SetMap(map[string]interface{}{
+		"address":         b.Address,
+		"amount":          b.Amount,
+		"fundable_claims": b.FundableClaims,
+	}).Suffix("ON CONFLICT (address) DO UPDATE SET amount = EXCLUDED.amount, fundable_claims = EXCLUDED.fundable_claims, updated_at = NOW()")
+
+	if err := q.db.Exec(stmt); err != nil {
+		return fmt.Errorf("upsert balance %+v: %w", b, err)
+	}
+
+	return nil
+}
+
+func (q *BalancesQ) Select() ([]Balance, error) {
+	var res []Balance
+
+	if err := q.db.Select(&res, q.selector); err != nil {
+		return nil, fmt.Errorf("select balances: %w", err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,84 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+const eventsTable = "airdrop_events"
+
+// Actors of the airdrop status transitions
+const (
+	ActorAPI         = "api"
+	ActorBroadcaster = "broadcaster"
+	ActorAdmin       = "admin"
+)
+
+// Event is the airdrop status transition. It is written in the same statement
+// as the airdrop itself, see AirdropsQ.Insert and AirdropsQ.UpdateMany.
+type Event struct {
+	ID        int64  `db:"id"`
+	AirdropID string `db:"airdrop_id"`
+	// OldStatus is nil for the airdrop creation
+	OldStatus *string   `db:"old_status"`
+	NewStatus string    `db:"new_status"`
+	TxHash    *string   `db:"tx_hash"`
+	Error     *string   `db:"error"`
+	Actor     string    `db:"actor"`
+	ActorID   *string   `db:"actor_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type EventsQ struct {
+	db       *pgdb.DB
+	selector squirrel.SelectBuilder
+}
+
+func NewEventsQ(db *pgdb.DB) *EventsQ {
+	return &EventsQ{
+		db:       db,
+		selector: squirrel.Select(eventsTable + ".*").From(eventsTable).OrderBy(eventsTable + ".id"),
+	}
+}
+
+func (q *EventsQ) New() *EventsQ {
+	return NewEventsQ(q.db)
+}
+
+func (q *EventsQ) Select() ([]Event, error) {
+	var res []Event
+
+	if err := q.db.Select(&res, q.selector); err != nil {
+		return nil, fmt.Errorf("select airdrop events: %w", err)
+	}
+
+	return res, nil
+}
+
+// FilterByNullifier leaves the events of all the airdrops with the nullifier,
+// including the failed ones
+func (q *EventsQ) FilterByNullifier(nullifier string) *EventsQ {
+	q.selector = q.selector.
+		Join(airdropsTable + " ON " + airdropsTable + ".id = " + eventsTable + ".airdrop_id").
+		Where(squirrel.Eq{airdropsTable + ".nullifier": nullifier})
+	return q
+}
+
+func (q *EventsQ) FilterByAirdropID(id string) *EventsQ {
+	q.selector = q.selector.Where(squirrel.Eq{eventsTable + ".airdrop_id": id})
+	return q
+}
+
+func (q *EventsQ) FilterByID(ids ...int64) *EventsQ {
+	q.selector = q.selector.Where(squirrel.Eq{eventsTable + ".id": ids})
+	return q
+}
+
+// FilterAfter leaves the events with greater IDs, which are recorded later
+func (q *EventsQ) FilterAfter(id int64) *EventsQ {
+	q.selector = q.selector.Where(squirrel.Gt{eventsTable + ".id": id})
+	return q
+}
@@ -0,0 +1,251 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+const (
+	outboxTable     = "webhook_outbox"
+	deliveriesTable = "webhook_deliveries"
+	deadLettersView = "webhook_dead_letters"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	// DeliveryStatusDead is set when the delivery attempts are exhausted, the
+	// dead deliveries are listed in webhook_dead_letters view
+	DeliveryStatusDead = "dead"
+)
+
+// OutboxEntry is the airdrop event to be delivered to the webhook
+// subscriptions. It is written in the same statement as the event, see
+// AirdropsQ.Insert and AirdropsQ.UpdateMany, and deleted once dispatched.
+type OutboxEntry struct {
+	ID      int64 `db:"id"`
+	EventID int64 `db:"event_id"`
+	// EventStatus is the new status of the event, which is used to match the
+	// subscriptions
+	EventStatus string    `db:"event_status"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Delivery is the event delivery to a single subscription
+type Delivery struct {
+	ID            int64     `db:"id"`
+	EventID       int64     `db:"event_id"`
+	Subscription  string    `db:"subscription"`
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	LastError     *string   `db:"last_error"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+// DeadLetter is the delivery which attempts are exhausted along with the
+// event details
+type DeadLetter struct {
+	Delivery
+	AirdropID   string `db:"airdrop_id"`
+	Nullifier   string `db:"nullifier"`
+	EventStatus string `db:"event_status"`
+}
+
+// outboxInsert returns the statement enqueuing the events inserted in event
+// CTE, so the events and the outbox entries are written atomically
+func outboxInsert() squirrel.InsertBuilder {
+	return squirrel.Insert(outboxTable).
+		Columns("event_id").
+		Select(squirrel.Select("id").From("event"))
+}
+
+type OutboxQ struct {
+	db       *pgdb.DB
+	selector squirrel.SelectBuilder
+}
+
+func NewOutboxQ(db *pgdb.DB) *OutboxQ {
+	return &OutboxQ{
+		db: db,
+		selector: squirrel.Select(outboxTable+".*", eventsTable+".new_status AS event_status").
+			From(outboxTable).
+			Join(eventsTable + " ON " + eventsTable + ".id = " + outboxTable + ".event_id").
+			OrderBy(outboxTable + ".id"),
+	}
+}
+
+func (q *OutboxQ) New() *OutboxQ {
+	return NewOutboxQ(q.db)
+}
+
+func (q *OutboxQ) Transaction(fn func() error) error {
+	return q.db.Transaction(fn)
+}
+
+// SelectUndispatched locks and returns the oldest entries, skipping the ones
+// locked by other replicas. It must be called in transaction along with
+// Delete.
+func (q *OutboxQ) SelectUndispatched(limit uint64) ([]OutboxEntry, error) {
+	var res []OutboxEntry
+	stmt := q.selector.
+		Limit(limit).
+		Suffix("FOR UPDATE OF " + outboxTable + " SKIP LOCKED")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("select undispatched outbox entries: %w", err)
+	}
+
+	return res, nil
+}
+
+// Delete removes the dispatched entries
+func (q *OutboxQ) Delete(ids []int64) error {
+	stmt := squirrel.Delete(outboxTable).Where(squirrel.Eq{"id": ids})
+
+	if err := q.db.Exec(stmt); err != nil {
+		return fmt.Errorf("delete dispatched outbox entries [ids=%v]: %w", ids, err)
+	}
+
+	return nil
+}
+
+type DeliveriesQ struct {
+	db *pgdb.DB
+}
+
+func NewDeliveriesQ(db *pgdb.DB) *DeliveriesQ {
+	return &DeliveriesQ{db: db}
+}
+
+func (q *DeliveriesQ) New() *DeliveriesQ {
+	return NewDeliveriesQ(q.db)
+}
+
+// Insert creates pending deliveries, skipping the existing ones
+func (q *DeliveriesQ) Insert(deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	stmt := squirrel.Insert(deliveriesTable).Columns("event_id", "subscription")
+	for _, d := range deliveries {
+		stmt = stmt.Values(d.EventID, d.Subscription)
+	}
+	stmt = stmt.Suffix("ON CONFLICT (event_id, subscription) DO NOTHING")
+
+	if err := q.db.Exec(stmt); err != nil {
+		return fmt.Errorf("insert webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Claim returns the pending deliveries of the subscriptions which backoff has
+// passed, incrementing the attempts. The next attempt is postponed by lease,
+// so the delivery is neither taken by other replicas in the meantime nor lost
+// when the worker dies.
+func (q *DeliveriesQ) Claim(subscriptions []string, limit uint64, lease time.Duration) ([]Delivery, error) {
+	var res []Delivery
+
+	ready := squirrel.Select("id").
+		From(deliveriesTable).
+		Where(squirrel.Eq{"status": DeliveryStatusPending, "subscription": subscriptions}).
+		Where("next_attempt_at <= NOW()").
+		OrderBy("id").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	stmt := squirrel.Update(deliveriesTable).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("next_attempt_at", NowAfter(lease)).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Expr("id IN (?)", ready)).
+		Suffix("RETURNING *")
+
+	if err := q.db.Select(&res, stmt); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	return res, nil
+}
+
+func (q *DeliveriesQ) Update(id int64, values map[string]any) error {
+	stmt := squirrel.Update(deliveriesTable).
+		SetMap(values).
+		Set("updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id})
+
+	if err := q.db.Exec(stmt); err != nil {
+		return fmt.Errorf("update webhook delivery [id=%d values=%v]: %w", id, values, err)
+	}
+
+	return nil
+}
+
+type DeadLettersQ struct {
+	db       *pgdb.DB
+	selector squirrel.SelectBuilder
+}
+
+func NewDeadLettersQ(db *pgdb.DB) *DeadLettersQ {
+	return &DeadLettersQ{
+		db:       db,
+		selector: squirrel.Select("*").From(deadLettersView),
+	}
+}
+
+func (q *DeadLettersQ) New() *DeadLettersQ {
+	return NewDeadLettersQ(q.db)
+}
+
+func (q *DeadLettersQ) Select() ([]DeadLetter, error) {
+	var res []DeadLetter
+
+	if err := q.db.Select(&res, q.selector); err != nil {
+		return nil, fmt.Errorf("select webhook dead letters: %w", err)
+	}
+
+	return res, nil
+}
+
+// Requeue puts the dead delivery back to the queue with reset attempts. Nil is
+// returned when there is no dead delivery with the ID.
+func (q *DeadLettersQ) Requeue(id int64) (*Delivery, error) {
+	var res Delivery
+	stmt := squirrel.Update(deliveriesTable).
+		SetMap(map[string]any{
+			"status":          DeliveryStatusPending,
+			"attempts":        0,
+			"next_attempt_at": NowAfter(0),
+			"updated_at":      squirrel.Expr("NOW()"),
+		}).
+		Where(squirrel.Eq{"id": id, "status": DeliveryStatusDead}).
+		Suffix("RETURNING *")
+
+	err := q.db.Get(&res, stmt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("requeue webhook delivery [id=%d]: %w", id, err)
+	}
+
+	return &res, nil
+}
+
+func (q *DeadLettersQ) FilterBySubscription(subscription string) *DeadLettersQ {
+	q.selector = q.selector.Where(squirrel.Eq{"subscription": subscription})
+	return q
+}
+
+func (q *DeadLettersQ) Page(params *pgdb.OffsetPageParams) *DeadLettersQ {
+	q.selector = params.ApplyTo(q.selector, "id")
+	return q
+}
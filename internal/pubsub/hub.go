@@ -0,0 +1,58 @@
+// Package pubsub delivers the airdrop updates from the broadcaster to the API
+// within the same process.
+package pubsub
+
+import "sync"
+
+// subscriptionBuffer is the amount of undelivered updates per subscriber,
+// the rest is dropped, so subscribers must poll the DB periodically as well
+const subscriptionBuffer = 16
+
+// Hub broadcasts the IDs of updated airdrops to all the subscribers. Publish
+// never blocks, so a slow subscriber can't delay the broadcaster.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	// C receives the IDs of updated airdrops
+	C   chan []string
+	hub *Hub
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe() *Subscription {
+	sub := &Subscription{
+		C:   make(chan []string, subscriptionBuffer),
+		hub: h,
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+func (h *Hub) Publish(ids []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		select {
+		case sub.C <- ids:
+		default:
+		}
+	}
+}
+
+// Close unsubscribes, the channel is not closed to not race with Publish
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminCancelAirdrop cancels the pending airdrop, which is not being sent yet
+func AdminCancelAirdrop(w http.ResponseWriter, r *http.Request) {
+	id, err := requests.NewAdminAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	adminTransition(w, r, id, []string{data.TxStatusPending}, map[string]any{
+		"status": data.TxStatusCancelled,
+	})
+}
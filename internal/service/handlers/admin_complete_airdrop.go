@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminCompleteAirdrop marks the airdrop completed with the tx which was sent
+// manually. The airdrops in flight can't be completed, because they are
+// settled by the broadcaster.
+func AdminCompleteAirdrop(w http.ResponseWriter, r *http.Request) {
+	req, err := requests.NewCompleteAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	from := []string{data.TxStatusPending, data.TxStatusFailed, data.TxStatusQuarantined}
+	adminTransition(w, r, req.ID, from, map[string]any{
+		"status":    data.TxStatusCompleted,
+		"tx_hash":   req.TxHash,
+		"tx_height": nil,
+	})
+}
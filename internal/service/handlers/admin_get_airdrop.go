@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+func AdminGetAirdrop(w http.ResponseWriter, r *http.Request) {
+	id, err := requests.NewAdminAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	airdrop, err := AirdropsQ(r).FilterByID(id).Get()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to get airdrop by ID")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if airdrop == nil {
+		ape.RenderErr(w, problems.NotFound())
+		return
+	}
+
+	ape.Render(w, resources.AirdropResponse{Data: toAdminAirdrop(*airdrop)})
+}
+
+// adminTransition changes the airdrop status on behalf of the admin, when the
+// airdrop has one of the expected statuses, and renders the result
+func adminTransition(w http.ResponseWriter, r *http.Request, id string, from []string, values map[string]any) {
+	airdrops, err := AirdropsQ(r).
+		WithActor(data.ActorAdmin, Caller(r).Name).
+		Transition([]string{id}, from, values)
+	if errors.Is(err, data.ErrNullifierConflict) {
+		ape.RenderErr(w, problems.Conflict())
+		return
+	}
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to change airdrop status by admin")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	if len(airdrops) == 0 {
+		airdrop, err := AirdropsQ(r).FilterByID(id).Get()
+		if err != nil {
+			Log(r).WithError(err).Error("Failed to get airdrop by ID")
+			ape.RenderErr(w, problems.InternalError())
+			return
+		}
+		if airdrop == nil {
+			ape.RenderErr(w, problems.NotFound())
+			return
+		}
+
+		conflict := problems.Conflict()
+		conflict.Detail = fmt.Sprintf("airdrop is %s, expected one of %v", airdrop.Status, from)
+		ape.RenderErr(w, conflict)
+		return
+	}
+
+	AirdropUpdates(r).Publish([]string{id})
+	Log(r).WithFields(map[string]any{
+		"admin":   Caller(r).Name,
+		"airdrop": id,
+		"status":  airdrops[0].Status,
+	}).Info("Airdrop status changed by admin")
+	ape.Render(w, resources.AirdropResponse{Data: toAdminAirdrop(airdrops[0])})
+}
+
+// toAdminAirdrop renders the airdrop with the fields hidden from users
+func toAdminAirdrop(a data.Airdrop) resources.Airdrop {
+	res := toAirdropResponse(a).Data
+	attempts := int32(a.Attempts)
+	res.Attributes.Attempts = &attempts
+	res.Attributes.LastError = a.LastError
+	res.Attributes.Sender = a.Sender
+	res.Attributes.TxHeight = a.TxHeight
+	return res
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminGetAirdropTimeline renders the status transitions of the airdrop along
+// with the actors identities and the errors
+func AdminGetAirdropTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := requests.NewAdminAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	events, err := EventsQ(r).FilterByAirdropID(id).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select airdrop events by airdrop ID")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if len(events) == 0 {
+		ape.RenderErr(w, problems.NotFound())
+		return
+	}
+
+	list := make([]resources.AirdropEvent, len(events))
+	for i, e := range events {
+		list[i] = toAdminAirdropEvent(e)
+	}
+
+	ape.Render(w, resources.AirdropEventListResponse{Data: list})
+}
+
+// toAdminAirdropEvent renders the event with the fields hidden from users
+func toAdminAirdropEvent(e data.Event) resources.AirdropEvent {
+	res := toAirdropEvent(e)
+	res.Attributes.Error = e.Error
+	res.Attributes.ActorId = e.ActorID
+	return res
+}
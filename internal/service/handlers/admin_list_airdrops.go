@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+func AdminListAirdrops(w http.ResponseWriter, r *http.Request) {
+	req, err := requests.NewListAirdrops(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	q := AirdropsQ(r).FilterByCreatedAt(req.CreatedAfter, req.CreatedBefore)
+	if len(req.Statuses) > 0 {
+		q = q.FilterByStatus(req.Statuses...)
+	}
+	if req.Address != "" {
+		q = q.FilterByAddress(req.Address)
+	}
+
+	airdrops, err := q.Page(&req.OffsetPageParams).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select airdrops")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	list := make([]resources.Airdrop, len(airdrops))
+	for i, a := range airdrops {
+		list[i] = toAdminAirdrop(a)
+	}
+
+	links := &resources.Links{Self: pageLink(r, req.PageNumber)}
+	if uint64(len(airdrops)) == req.Limit {
+		links.Next = pageLink(r, req.PageNumber+1)
+	}
+
+	ape.Render(w, resources.AirdropListResponse{Data: list, Links: links})
+}
+
+func pageLink(r *http.Request, number uint64) string {
+	query := r.URL.Query()
+	query.Set("page[number]", fmt.Sprint(number))
+	return r.URL.Path + "?" + query.Encode()
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminListDeadLetters renders the webhook deliveries which attempts are
+// exhausted
+func AdminListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	req, err := requests.NewListDeadLetters(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	q := DeadLettersQ(r)
+	if req.Subscription != "" {
+		q = q.FilterBySubscription(req.Subscription)
+	}
+
+	letters, err := q.Page(&req.OffsetPageParams).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select webhook dead letters")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	list := make([]resources.WebhookDelivery, len(letters))
+	for i, l := range letters {
+		list[i] = toWebhookDelivery(l)
+	}
+
+	links := &resources.Links{Self: pageLink(r, req.PageNumber)}
+	if uint64(len(letters)) == req.Limit {
+		links.Next = pageLink(r, req.PageNumber+1)
+	}
+
+	ape.Render(w, resources.WebhookDeliveryListResponse{Data: list, Links: links})
+}
+
+func toWebhookDelivery(l data.DeadLetter) resources.WebhookDelivery {
+	return resources.WebhookDelivery{
+		Key: resources.NewKeyInt64(l.ID, resources.WEBHOOK_DELIVERY),
+		Attributes: resources.WebhookDeliveryAttributes{
+			Subscription: l.Subscription,
+			Status:       l.Status,
+			Attempts:     int32(l.Attempts),
+			LastError:    l.LastError,
+			EventId:      strconv.FormatInt(l.EventID, 10),
+			EventStatus:  l.EventStatus,
+			AirdropId:    l.AirdropID,
+			Nullifier:    l.Nullifier,
+			CreatedAt:    l.CreatedAt,
+			UpdatedAt:    l.UpdatedAt,
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminRedeliverWebhook puts the dead webhook delivery back to the queue with
+// reset attempts, e.g. after the partner endpoint is fixed
+func AdminRedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := requests.NewAdminWebhookDelivery(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	delivery, err := DeadLettersQ(r).Requeue(id)
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to requeue webhook delivery")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if delivery == nil {
+		ape.RenderErr(w, problems.NotFound())
+		return
+	}
+
+	Log(r).WithFields(map[string]any{
+		"admin":        Caller(r).Name,
+		"delivery":     id,
+		"subscription": delivery.Subscription,
+	}).Info("Webhook delivery requeued by admin")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// AdminRetryAirdrop puts the failed or quarantined airdrop back to the queue
+// with reset attempts. The quarantined airdrop holds the nullifier, so the
+// retry is the only way to send it again, which must be done only when its
+// last tx is known to be absent on chain. Conflict is returned when the user
+// has already claimed again.
+func AdminRetryAirdrop(w http.ResponseWriter, r *http.Request) {
+	id, err := requests.NewAdminAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	adminTransition(w, r, id, []string{data.TxStatusFailed, data.TxStatusQuarantined}, map[string]any{
+		"status":                data.TxStatusPending,
+		"attempts":              0,
+		"next_attempt_at":       data.NowAfter(0),
+		"tx_hash":               nil,
+		"tx_height":             nil,
+		"tx_sequence":           nil,
+		"tx_sequence_passed_at": nil,
+		"last_error":            nil,
+		"sender":                nil,
+	})
+}
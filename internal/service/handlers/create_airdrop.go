@@ -3,8 +3,10 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/cosmos/cosmos-sdk/types"
+	val "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/rarimo/airdrop-svc/internal/data"
 	"github.com/rarimo/airdrop-svc/internal/service/requests"
 	zk "github.com/rarimo/zkverifier-kit"
@@ -24,21 +26,16 @@ func CreateAirdrop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nullifier := req.Data.Attributes.ZkProof.PubSignals[zk.Nullifier]
-
-	airdrop, err := AirdropsQ(r).
-		FilterByNullifier(nullifier).
-		FilterByStatus(data.TxStatusCompleted).
-		Get()
-	if err != nil {
-		Log(r).WithError(err).Error("Failed to get airdrop by nullifier")
-		ape.RenderErr(w, problems.InternalError())
-		return
-	}
-	if airdrop != nil {
-		ape.RenderErr(w, problems.Conflict())
+	// the nullifier is read before the verification to respond to the retries
+	// without verifying the proof again
+	signals := req.Data.Attributes.ZkProof.PubSignals
+	if len(signals) <= int(zk.Nullifier) {
+		renderProofErrors(w, val.Errors{
+			malformedProofPrefix + "pub_signals": errors.New("nullifier public signal is missing"),
+		})
 		return
 	}
+	nullifier := signals[zk.Nullifier]
 
 	addr, err := types.AccAddressFromBech32(req.Data.Attributes.Address)
 	if err != nil {
@@ -49,6 +46,20 @@ func CreateAirdrop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	airdrop, err := getActiveAirdrop(r, nullifier)
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to get airdrop by nullifier")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	// the proof is bound to the address, so the retry with the same address
+	// is answered without the verification, while the others must prove that
+	// they own the nullifier first
+	if airdrop != nil && strings.EqualFold(airdrop.Address, req.Data.Attributes.Address) {
+		renderExistingAirdrop(w, *airdrop)
+		return
+	}
+
 	err = Verifier(r).VerifyProof(req.Data.Attributes.ZkProof, zk.WithEventData(addr.Bytes()))
 	if err != nil {
 		if errors.Is(err, identity.ErrContractCall) {
@@ -58,16 +69,43 @@ func CreateAirdrop(w http.ResponseWriter, r *http.Request) {
 		}
 
 		Log(r).WithError(err).Info("Invalid proof")
+		var errs val.Errors
+		if errors.As(err, &errs) {
+			renderProofErrors(w, errs)
+			return
+		}
 		ape.RenderErr(w, problems.BadRequest(err)...)
 		return
 	}
 
+	if airdrop != nil {
+		// the nullifier is already used by the airdrop to another address
+		ape.RenderErr(w, problems.Conflict())
+		return
+	}
+
 	airdrop, err = AirdropsQ(r).Insert(data.Airdrop{
 		Nullifier: nullifier,
 		Address:   req.Data.Attributes.Address,
 		Amount:    AirdropAmount(r),
 		Status:    data.TxStatusPending,
 	})
+	if errors.Is(err, data.ErrNullifierConflict) {
+		// a concurrent request has inserted the airdrop after our check
+		airdrop, err = getActiveAirdrop(r, nullifier)
+		if err != nil || airdrop == nil {
+			Log(r).WithError(err).Error("Failed to get conflicting airdrop by nullifier")
+			ape.RenderErr(w, problems.InternalError())
+			return
+		}
+		if !strings.EqualFold(airdrop.Address, req.Data.Attributes.Address) {
+			ape.RenderErr(w, problems.Conflict())
+			return
+		}
+
+		renderExistingAirdrop(w, *airdrop)
+		return
+	}
 	if err != nil {
 		Log(r).WithError(err).Errorf("Failed to insert airdrop")
 		ape.RenderErr(w, problems.InternalError())
@@ -76,3 +114,31 @@ func CreateAirdrop(w http.ResponseWriter, r *http.Request) {
 
 	ape.Render(w, toAirdropResponse(*airdrop))
 }
+
+// getActiveAirdrop returns the queued, completed or cancelled airdrop for the
+// nullifier, if any. Failed airdrops do not block a new claim.
+func getActiveAirdrop(r *http.Request, nullifier string) (*data.Airdrop, error) {
+	return AirdropsQ(r).
+		FilterByNullifier(nullifier).
+		FilterByStatus(
+			data.TxStatusPending,
+			data.TxStatusProcessing,
+			data.TxStatusSubmitted,
+			data.TxStatusCompleted,
+			data.TxStatusQuarantined,
+			data.TxStatusCancelled,
+		).
+		Get()
+}
+
+// renderExistingAirdrop responds with the queued airdrop, so that the client
+// retries are idempotent, or with conflict when the airdrop was already done
+// or cancelled.
+func renderExistingAirdrop(w http.ResponseWriter, airdrop data.Airdrop) {
+	if airdrop.Status == data.TxStatusCompleted || airdrop.Status == data.TxStatusCancelled {
+		ape.RenderErr(w, problems.Conflict())
+		return
+	}
+
+	ape.Render(w, toAirdropResponse(airdrop))
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/rarimo/airdrop-svc/internal/auth"
 	"github.com/rarimo/airdrop-svc/internal/config"
 	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/pubsub"
 	zk "github.com/rarimo/zkverifier-kit"
 	"gitlab.com/distributed_lab/logan/v3"
 )
@@ -15,9 +17,15 @@ type ctxKey int
 const (
 	logCtxKey ctxKey = iota
 	airdropsQCtxKey
+	balancesQCtxKey
+	eventsQCtxKey
 	airdropAmountCtxKey
 	verifierCtxKey
-	airdropParamsCtxKey
+	callerCtxKey
+	statsCtxKey
+	airdropUpdatesCtxKey
+	deadLettersQCtxKey
+	streamLimiterCtxKey
 )
 
 func CtxLog(entry *logan.Entry) func(context.Context) context.Context {
@@ -40,6 +48,36 @@ func AirdropsQ(r *http.Request) *data.AirdropsQ {
 	return r.Context().Value(airdropsQCtxKey).(*data.AirdropsQ).New()
 }
 
+func CtxBalancesQ(q *data.BalancesQ) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, balancesQCtxKey, q)
+	}
+}
+
+func BalancesQ(r *http.Request) *data.BalancesQ {
+	return r.Context().Value(balancesQCtxKey).(*data.BalancesQ).New()
+}
+
+func CtxEventsQ(q *data.EventsQ) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, eventsQCtxKey, q)
+	}
+}
+
+func EventsQ(r *http.Request) *data.EventsQ {
+	return r.Context().Value(eventsQCtxKey).(*data.EventsQ).New()
+}
+
+func CtxDeadLettersQ(q *data.DeadLettersQ) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, deadLettersQCtxKey, q)
+	}
+}
+
+func DeadLettersQ(r *http.Request) *data.DeadLettersQ {
+	return r.Context().Value(deadLettersQCtxKey).(*data.DeadLettersQ).New()
+}
+
 func CtxAirdropAmount(amount string) func(context.Context) context.Context {
 	return func(ctx context.Context) context.Context {
 		return context.WithValue(ctx, airdropAmountCtxKey, amount)
@@ -50,22 +88,66 @@ func AirdropAmount(r *http.Request) string {
 	return r.Context().Value(airdropAmountCtxKey).(string)
 }
 
-func CtxAirdropParams(amount config.GlobalParams) func(context.Context) context.Context {
+// CtxVerifier stores the current verifier of the config for each request, so
+// the verifier and the params of a single request always match, even when
+// they are reloaded concurrently
+func CtxVerifier(cfg *config.Config) func(context.Context) context.Context {
+	// the initial verifier is built on startup, so the invalid config fails
+	// fast instead of on the first request
+	cfg.Verifier()
+
 	return func(ctx context.Context) context.Context {
-		return context.WithValue(ctx, airdropParamsCtxKey, amount)
+		return context.WithValue(ctx, verifierCtxKey, cfg.Verifier())
 	}
 }
 
 func AirdropParams(r *http.Request) config.GlobalParams {
-	return r.Context().Value(airdropParamsCtxKey).(config.GlobalParams)
+	return r.Context().Value(verifierCtxKey).(*config.Verifierer).Params
 }
 
-func CtxVerifier(entry *zk.Verifier) func(context.Context) context.Context {
+func Verifier(r *http.Request) *zk.Verifier {
+	return r.Context().Value(verifierCtxKey).(*config.Verifierer).ZkVerifier
+}
+
+func CtxCaller(caller *auth.Caller) func(context.Context) context.Context {
 	return func(ctx context.Context) context.Context {
-		return context.WithValue(ctx, verifierCtxKey, entry)
+		return context.WithValue(ctx, callerCtxKey, caller)
 	}
 }
 
-func Verifier(r *http.Request) *zk.Verifier {
-	return r.Context().Value(verifierCtxKey).(*zk.Verifier)
+// Caller returns the authenticated caller or nil for anonymous requests, see
+// AuthMiddleware
+func Caller(r *http.Request) *auth.Caller {
+	caller, _ := r.Context().Value(callerCtxKey).(*auth.Caller)
+	return caller
+}
+
+func CtxStats(cache *StatsCache) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, statsCtxKey, cache)
+	}
+}
+
+func Stats(r *http.Request) *StatsCache {
+	return r.Context().Value(statsCtxKey).(*StatsCache)
+}
+
+func CtxAirdropUpdates(hub *pubsub.Hub) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, airdropUpdatesCtxKey, hub)
+	}
+}
+
+func AirdropUpdates(r *http.Request) *pubsub.Hub {
+	return r.Context().Value(airdropUpdatesCtxKey).(*pubsub.Hub)
+}
+
+func CtxStreams(limiter *StreamLimiter) func(context.Context) context.Context {
+	return func(ctx context.Context) context.Context {
+		return context.WithValue(ctx, streamLimiterCtxKey, limiter)
+	}
+}
+
+func Streams(r *http.Request) *StreamLimiter {
+	return r.Context().Value(streamLimiterCtxKey).(*StreamLimiter)
 }
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+func GetAirdropBalance(w http.ResponseWriter, r *http.Request) {
+	balances, err := BalancesQ(r).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select sender balances")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if len(balances) == 0 {
+		ape.RenderErr(w, problems.NotFound())
+		return
+	}
+
+	attr, err := totalBalance(balances)
+	if err != nil {
+		Log(r).WithError(err).Error("Invalid sender balance")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	ape.Render(w, resources.BalanceResponse{
+		Data: resources.Balance{
+			Key: resources.Key{
+				Type: resources.BALANCE,
+			},
+			Attributes: attr,
+		},
+	})
+}
+
+// totalBalance sums the balances of all the senders, the update time is the
+// earliest one, so the staleness is not hidden
+func totalBalance(balances []data.Balance) (resources.BalanceAttributes, error) {
+	var (
+		amount = types.NewCoins()
+		attr   = resources.BalanceAttributes{UpdatedAt: balances[0].UpdatedAt}
+	)
+	for _, b := range balances {
+		coins, err := types.ParseCoinsNormalized(b.Amount)
+		if err != nil {
+			return attr, fmt.Errorf("parse balance of sender %s: %w", b.Address, err)
+		}
+
+		amount = amount.Add(coins...)
+		attr.FundableClaims += b.FundableClaims
+		if b.UpdatedAt.Before(attr.UpdatedAt) {
+			attr.UpdatedAt = b.UpdatedAt
+		}
+	}
+	attr.Amount = amount.String()
+
+	return attr, nil
+}
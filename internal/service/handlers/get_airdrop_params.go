@@ -5,19 +5,49 @@ import (
 
 	"github.com/rarimo/airdrop-svc/resources"
 	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
 )
 
+// GetAirdropParams renders all the constraints of the proof verification, so
+// the client can build the proof and tell whether the user is eligible, along
+// with the airdrop amount and the remaining budget, if it is known
 func GetAirdropParams(w http.ResponseWriter, r *http.Request) {
+	params := AirdropParams(r)
+	attr := resources.AirdropParamsAttributes{
+		EventId:             params.EventID,
+		StartedAt:           params.AirdropStart,
+		QuerySelector:       params.QuerySelector,
+		MinAge:              int32(params.MinAge),
+		AllowedCitizenships: params.Citizenships,
+		MaxIdentityCount:    params.MaxIdentityCount,
+		Amount:              AirdropAmount(r),
+	}
+
+	balances, err := BalancesQ(r).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select sender balances")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	// the balances are saved by the broadcaster, so they are absent until its
+	// first run
+	if len(balances) > 0 {
+		total, err := totalBalance(balances)
+		if err != nil {
+			Log(r).WithError(err).Error("Invalid sender balance")
+			ape.RenderErr(w, problems.InternalError())
+			return
+		}
+		attr.RemainingBudget = &total.Amount
+		attr.RemainingClaims = &total.FundableClaims
+	}
+
 	ape.Render(w, resources.AirdropParamsResponse{
 		Data: resources.AirdropParams{
 			Key: resources.Key{
 				Type: resources.AIRDROP,
 			},
-			Attributes: resources.AirdropParamsAttributes{
-				EventId:       AirdropParams(r).EventID,
-				StartedAt:     AirdropParams(r).AirdropStart,
-				QuerySelector: AirdropParams(r).QuerySelector,
-			},
+			Attributes: attr,
 		},
 	})
 }
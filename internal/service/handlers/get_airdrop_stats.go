@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// StatsCache keeps the computed statistics for the interval, so that the
+// aggregate queries are not run on each request
+type StatsCache struct {
+	interval time.Duration
+
+	mu        sync.Mutex
+	stats     resources.AirdropStatsAttributes
+	expiresAt time.Time
+}
+
+func NewStatsCache(interval time.Duration) *StatsCache {
+	return &StatsCache{interval: interval}
+}
+
+// get returns the cached statistics or computes them when expired. The lock is
+// held during the computation to run the queries once for concurrent requests.
+func (c *StatsCache) get(compute func() (resources.AirdropStatsAttributes, error)) (resources.AirdropStatsAttributes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Now().Before(c.expiresAt) {
+		return c.stats, nil
+	}
+
+	stats, err := compute()
+	if err != nil {
+		return stats, err
+	}
+
+	c.stats, c.expiresAt = stats, time.Now().Add(c.interval)
+	return stats, nil
+}
+
+func GetAirdropStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := Stats(r).get(func() (resources.AirdropStatsAttributes, error) {
+		return computeStats(r)
+	})
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to compute airdrop stats")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	ape.Render(w, resources.AirdropStatsResponse{
+		Data: resources.AirdropStats{
+			Key: resources.Key{
+				Type: resources.AIRDROP_STATS,
+			},
+			Attributes: stats,
+		},
+	})
+}
+
+func computeStats(r *http.Request) (attr resources.AirdropStatsAttributes, err error) {
+	attr.UpdatedAt = time.Now().UTC()
+
+	byStatus, err := AirdropsQ(r).CountByStatus()
+	if err != nil {
+		return attr, err
+	}
+	attr.ByStatus = make(map[string]int64, len(byStatus))
+	for _, s := range byStatus {
+		attr.ByStatus[s.Status] = s.Count
+	}
+
+	byAmount, err := AirdropsQ(r).FilterByStatus(data.TxStatusCompleted).CountByAmount()
+	if err != nil {
+		return attr, err
+	}
+	total := types.NewCoins()
+	for _, a := range byAmount {
+		coins, err := types.ParseCoinsNormalized(a.Amount)
+		if err != nil {
+			return attr, fmt.Errorf("parse completed airdrop amount %s: %w", a.Amount, err)
+		}
+		for _, coin := range coins {
+			total = total.Add(types.NewCoin(coin.Denom, coin.Amount.MulRaw(a.Count)))
+		}
+	}
+	attr.TotalDistributed = total.String()
+
+	byDay, err := AirdropsQ(r).CountByDay()
+	if err != nil {
+		return attr, err
+	}
+	attr.ClaimsPerDay = make([]resources.DailyClaims, len(byDay))
+	for i, d := range byDay {
+		attr.ClaimsPerDay[i] = resources.DailyClaims{
+			Date:  d.Day.Format(time.DateOnly),
+			Count: d.Count,
+		}
+	}
+
+	median, err := AirdropsQ(r).MedianCompletionTime()
+	if err != nil {
+		return attr, err
+	}
+	if median != nil {
+		seconds := median.Seconds()
+		attr.MedianCompletionSeconds = &seconds
+	}
+
+	return attr, nil
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// GetAirdropTimeline renders the status transitions of all the airdrops with
+// the nullifier, including the failed ones, in chronological order
+func GetAirdropTimeline(w http.ResponseWriter, r *http.Request) {
+	nullifier, err := requests.NewGetAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	events, err := EventsQ(r).FilterByNullifier(nullifier).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select airdrop events by nullifier")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if len(events) == 0 {
+		ape.RenderErr(w, problems.NotFound())
+		return
+	}
+
+	ape.Render(w, toAirdropEventListResponse(events))
+}
+
+func toAirdropEventListResponse(events []data.Event) resources.AirdropEventListResponse {
+	list := make([]resources.AirdropEvent, len(events))
+	for i, e := range events {
+		list[i] = toAirdropEvent(e)
+	}
+
+	return resources.AirdropEventListResponse{Data: list}
+}
+
+// toAirdropEvent renders the event without the admin identity and the raw
+// error, which are only rendered to admin, see toAdminAirdropEvent
+func toAirdropEvent(e data.Event) resources.AirdropEvent {
+	return resources.AirdropEvent{
+		Key: resources.Key{
+			ID:   strconv.FormatInt(e.ID, 10),
+			Type: resources.AIRDROP_EVENT,
+		},
+		Attributes: resources.AirdropEventAttributes{
+			AirdropId: e.AirdropID,
+			OldStatus: e.OldStatus,
+			NewStatus: e.NewStatus,
+			TxHash:    e.TxHash,
+			Actor:     e.Actor,
+			CreatedAt: e.CreatedAt,
+		},
+	}
+}
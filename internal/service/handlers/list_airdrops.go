@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+// ListAirdrops looks up the airdrops by the destination address
+func ListAirdrops(w http.ResponseWriter, r *http.Request) {
+	req, err := requests.NewListUserAirdrops(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	q := AirdropsQ(r).FilterByAddress(req.Address)
+	if len(req.Statuses) > 0 {
+		q = q.FilterByStatus(req.Statuses...)
+	}
+
+	airdrops, err := q.PageAfter(req.Cursor, req.Limit, req.Order).Select()
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to select airdrops by address")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	list := make([]resources.Airdrop, len(airdrops))
+	for i, a := range airdrops {
+		list[i] = toAirdropResponse(a).Data
+	}
+
+	links := &resources.Links{Self: cursorLink(r, req.Cursor)}
+	if uint64(len(airdrops)) == req.Limit {
+		links.Next = cursorLink(r, airdrops[len(airdrops)-1].ID)
+	}
+
+	ape.Render(w, resources.AirdropListResponse{Data: list, Links: links})
+}
+
+func cursorLink(r *http.Request, cursor string) string {
+	query := r.URL.Query()
+	if cursor == "" {
+		query.Del("page[cursor]")
+	} else {
+		query.Set("page[cursor]", cursor)
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
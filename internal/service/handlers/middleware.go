@@ -3,8 +3,12 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 
+	"github.com/rarimo/airdrop-svc/internal/auth"
 	"github.com/rarimo/airdrop-svc/internal/data"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
 	"gitlab.com/distributed_lab/kit/pgdb"
 )
 
@@ -20,7 +24,10 @@ func DBCloneMiddleware(db *pgdb.DB) func(http.Handler) http.Handler {
 			ctx := r.Context()
 
 			extenders := []ctxExtender{
-				CtxAirdropsQ(data.NewAirdropsQ(clone)),
+				CtxAirdropsQ(data.NewAirdropsQ(clone, data.ActorAPI)),
+				CtxEventsQ(data.NewEventsQ(clone)),
+				CtxBalancesQ(data.NewBalancesQ(clone)),
+				CtxDeadLettersQ(data.NewDeadLettersQ(clone)),
 			}
 
 			for _, extender := range extenders {
@@ -30,3 +37,53 @@ func DBCloneMiddleware(db *pgdb.DB) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// AuthMiddleware authenticates the caller by the bearer token and puts it into
+// the context. Invalid credentials are rejected, while anonymous requests are
+// passed, so it must be mounted only on the privileged routes along with
+// RequireScope.
+func AuthMiddleware(authenticator *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				ape.RenderErr(w, problems.Unauthorized())
+				return
+			}
+
+			caller, err := authenticator.Authenticate(token)
+			if err != nil {
+				Log(r).WithError(err).Debug("Failed to authenticate caller")
+				ape.RenderErr(w, problems.Unauthorized())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(CtxCaller(caller)(r.Context())))
+		})
+	}
+}
+
+// RequireScope rejects anonymous requests and the callers without the scope
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			caller := Caller(r)
+			if caller == nil {
+				ape.RenderErr(w, problems.Unauthorized())
+				return
+			}
+			if !caller.HasScope(scope) {
+				ape.RenderErr(w, problems.Forbidden())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
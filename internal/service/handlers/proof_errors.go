@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/google/jsonapi"
+	zk "github.com/rarimo/zkverifier-kit"
+)
+
+// Stable codes of the proof rejection reasons, the clients rely on them, so
+// the codes must never be changed
+const (
+	codeMalformedProof        = "malformed_proof"
+	codeInvalidProof          = "invalid_proof"
+	codeInvalidEventID        = "invalid_event_id"
+	codeInvalidSelector       = "invalid_selector"
+	codeAgeBelowMinimum       = "age_below_minimum"
+	codeCitizenshipNotAllowed = "citizenship_not_allowed"
+	codeIdentityTooLate       = "identity_registered_too_late"
+	codePassportExpired       = "passport_expired"
+	codeInvalidIDStateRoot    = "invalid_id_state_root"
+	codeEventDataMismatch     = "event_data_mismatch"
+	codeNullifierRequired     = "nullifier_required"
+	codeNullifierAlreadyUsed  = "nullifier_already_used"
+	codeInvalidPubSignal      = "invalid_pub_signal"
+)
+
+// Pointers to the proof in CreateAirdrop request body
+const (
+	proofPointer      = "/data/attributes/zk_proof/proof"
+	pubSignalsPointer = "/data/attributes/zk_proof/pub_signals"
+)
+
+const (
+	// proofField is the error field of the groth16 verification, which is
+	// only done when the public signals are valid
+	proofField = "/proof"
+	// malformedProofPrefix is the prefix of the error fields of the proof
+	// structure validation, which is done before the signals are checked
+	malformedProofPrefix = "zk_proof/"
+	// nullifierUsedField is the error field of the nullifier used by another
+	// airdrop, which is only checked by VerifyAirdrop
+	nullifierUsedField = "pub_signals/nullifier_used"
+)
+
+// signalField is the key of zkverifier-kit validation error along with the
+// position of the validated public signal
+type signalField struct {
+	key    string
+	signal zk.PubSignal
+}
+
+// proofChecks are the checks of zkverifier-kit with the fields of their
+// validation errors. The age and identity checks pass when either of their
+// signals is valid, so the failure is reported for one of them.
+var proofChecks = []struct {
+	name   string
+	code   string
+	fields []signalField
+}{
+	{"event_id", codeInvalidEventID, []signalField{{"pub_signals/event_id", zk.EventID}}},
+	{"selector", codeInvalidSelector, []signalField{{"pub_signals/selector", zk.Selector}}},
+	{"age", codeAgeBelowMinimum, []signalField{
+		{"pub_signals/birth_date", zk.BirthDate},
+		{"pub_signals/birth_date_upper_bound", zk.BirthdateUpperBound},
+	}},
+	{"citizenship", codeCitizenshipNotAllowed, []signalField{{"pub_signals/citizenship", zk.Citizenship}}},
+	{"identity", codeIdentityTooLate, []signalField{
+		{"pub_signals/identity_counter_upper_bound", zk.IdentityCounterUpperBound},
+		{"pub_signals/timestamp_upper_bound", zk.TimestampUpperBound},
+	}},
+	{"passport_expiration", codePassportExpired, []signalField{
+		{"pub_signals/expiration_date", zk.ExpirationDate},
+		{"pub_signals/expiration_date_lower_bound", zk.ExpirationDateLowerBound},
+	}},
+	{"id_state_root", codeInvalidIDStateRoot, []signalField{{"pub_signals/id_state_root", zk.IdStateRoot}}},
+	{"address", codeEventDataMismatch, []signalField{{"pub_signals/event_data", zk.EventData}}},
+	{"nullifier", codeNullifierRequired, []signalField{
+		{"pub_signals/nullifier", zk.Nullifier},
+		{nullifierUsedField, zk.Nullifier},
+	}},
+}
+
+// proofError is JSON:API error object with source, which is missing in
+// jsonapi.ErrorObject. The meta is the same as problems.BadRequest renders.
+type proofError struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+	Status string `json:"status"`
+	Code   string `json:"code"`
+	Source struct {
+		Pointer string `json:"pointer"`
+	} `json:"source"`
+	Meta map[string]any `json:"meta"`
+}
+
+// isMalformedProof reports whether the proof could not be verified because
+// of its structure, so the public signals were not checked
+func isMalformedProof(errs val.Errors) bool {
+	for field := range errs {
+		if strings.HasPrefix(field, malformedProofPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderProofErrors renders an error with stable code and pointer to the
+// public signal for each failed field of zkverifier-kit validation
+func renderProofErrors(w http.ResponseWriter, errs val.Errors) {
+	w.Header().Set("content-type", jsonapi.MediaType)
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]any{"errors": toProofErrors(errs)})
+}
+
+func toProofErrors(errs val.Errors) []proofError {
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	res := make([]proofError, 0, len(fields))
+	for _, field := range fields {
+		code, pointer := proofErrorSource(field)
+		e := proofError{
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: errs[field].Error(),
+			Status: fmt.Sprint(http.StatusBadRequest),
+			Code:   code,
+			Meta: map[string]any{
+				"field": field,
+				"error": errs[field].Error(),
+			},
+		}
+		e.Source.Pointer = pointer
+		res = append(res, e)
+	}
+
+	return res
+}
+
+// proofErrorSource returns the code and the request body pointer of the
+// failed field, the unknown fields of pub signals point to the whole array
+func proofErrorSource(field string) (code, pointer string) {
+	switch field {
+	case proofField:
+		return codeInvalidProof, proofPointer
+	case malformedProofPrefix + "proof":
+		return codeMalformedProof, proofPointer
+	case malformedProofPrefix + "pub_signals":
+		return codeMalformedProof, pubSignalsPointer
+	case nullifierUsedField:
+		return codeNullifierAlreadyUsed, fmt.Sprintf("%s/%d", pubSignalsPointer, zk.Nullifier)
+	}
+
+	for _, check := range proofChecks {
+		for _, f := range check.fields {
+			if f.key == field {
+				return check.code, fmt.Sprintf("%s/%d", pubSignalsPointer, f.signal)
+			}
+		}
+	}
+
+	return codeInvalidPubSignal, pubSignalsPointer
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rarimo/airdrop-svc/resources"
+)
+
+// The fields are the keys of zkverifier-kit validation errors, the codes and
+// pointers are the documented contract, see invalidProof response
+func TestProofErrorSource(t *testing.T) {
+	cases := []struct {
+		field   string
+		code    string
+		pointer string
+	}{
+		{"/proof", "invalid_proof", "/data/attributes/zk_proof/proof"},
+		{"zk_proof/proof", "malformed_proof", "/data/attributes/zk_proof/proof"},
+		{"zk_proof/pub_signals", "malformed_proof", "/data/attributes/zk_proof/pub_signals"},
+		{"pub_signals/nullifier", "nullifier_required", "/data/attributes/zk_proof/pub_signals/0"},
+		{"pub_signals/nullifier_used", "nullifier_already_used", "/data/attributes/zk_proof/pub_signals/0"},
+		{"pub_signals/birth_date", "age_below_minimum", "/data/attributes/zk_proof/pub_signals/1"},
+		{"pub_signals/birth_date_upper_bound", "age_below_minimum", "/data/attributes/zk_proof/pub_signals/18"},
+		{"pub_signals/expiration_date", "passport_expired", "/data/attributes/zk_proof/pub_signals/2"},
+		{"pub_signals/expiration_date_lower_bound", "passport_expired", "/data/attributes/zk_proof/pub_signals/19"},
+		{"pub_signals/citizenship", "citizenship_not_allowed", "/data/attributes/zk_proof/pub_signals/6"},
+		{"pub_signals/event_id", "invalid_event_id", "/data/attributes/zk_proof/pub_signals/9"},
+		{"pub_signals/event_data", "event_data_mismatch", "/data/attributes/zk_proof/pub_signals/10"},
+		{"pub_signals/id_state_root", "invalid_id_state_root", "/data/attributes/zk_proof/pub_signals/11"},
+		{"pub_signals/selector", "invalid_selector", "/data/attributes/zk_proof/pub_signals/12"},
+		{"pub_signals/timestamp_upper_bound", "identity_registered_too_late", "/data/attributes/zk_proof/pub_signals/14"},
+		{"pub_signals/identity_counter_upper_bound", "identity_registered_too_late", "/data/attributes/zk_proof/pub_signals/16"},
+		{"pub_signals/sex", "invalid_pub_signal", "/data/attributes/zk_proof/pub_signals"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.field, func(t *testing.T) {
+			code, pointer := proofErrorSource(c.field)
+			if code != c.code || pointer != c.pointer {
+				t.Fatalf("proofErrorSource(%q) = (%s, %s), want (%s, %s)", c.field, code, pointer, c.code, c.pointer)
+			}
+		})
+	}
+}
+
+func TestIsMalformedProof(t *testing.T) {
+	cases := []struct {
+		name string
+		errs val.Errors
+		want bool
+	}{
+		{"no errors", nil, false},
+		{"missing proof", val.Errors{"zk_proof/proof": errors.New("cannot be blank")}, true},
+		{"wrong signals amount", val.Errors{"zk_proof/pub_signals": errors.New("the length must be exactly 22")}, true},
+		{"invalid signal", val.Errors{"pub_signals/citizenship": errors.New("must be a valid value")}, false},
+		{"groth16 failure", val.Errors{"/proof": errors.New("groth16 verification failed")}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isMalformedProof(c.errs); got != c.want {
+				t.Fatalf("isMalformedProof(%v) = %t, want %t", c.errs, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRenderProofErrors(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderProofErrors(w, val.Errors{
+		"pub_signals/event_id":    errors.New("must be a valid value"),
+		"pub_signals/citizenship": errors.New("must be a valid value"),
+	})
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", w.Code)
+	}
+
+	var body struct {
+		Errors []proofError `json:"errors"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+
+	// the errors are sorted by field, so the response is deterministic
+	want := []struct{ code, pointer, field string }{
+		{"citizenship_not_allowed", "/data/attributes/zk_proof/pub_signals/6", "pub_signals/citizenship"},
+		{"invalid_event_id", "/data/attributes/zk_proof/pub_signals/9", "pub_signals/event_id"},
+	}
+	if len(body.Errors) != len(want) {
+		t.Fatalf("expected %d errors, got %d", len(want), len(body.Errors))
+	}
+	for i, e := range body.Errors {
+		if e.Code != want[i].code || e.Source.Pointer != want[i].pointer || e.Meta["field"] != want[i].field {
+			t.Fatalf("unexpected error #%d: %+v", i, e)
+		}
+		if e.Status != "400" || e.Detail != "must be a valid value" {
+			t.Fatalf("unexpected error #%d status or detail: %+v", i, e)
+		}
+	}
+}
+
+func TestToProofChecks(t *testing.T) {
+	checks := toProofChecks(val.Errors{
+		"pub_signals/citizenship": errors.New("must be a valid value"),
+		nullifierUsedField:        errors.New("nullifier is already used by pending airdrop"),
+		"pub_signals/sex":         errors.New("must be a valid value"),
+	}, false)
+
+	byName := make(map[string]resources.ProofCheck, len(checks))
+	for _, c := range checks {
+		byName[c.Name] = c
+	}
+
+	cases := []struct {
+		name   string
+		status string
+		code   string
+	}{
+		{"event_id", checkPassed, ""},
+		{"citizenship", checkFailed, "citizenship_not_allowed"},
+		{"nullifier", checkFailed, "nullifier_already_used"},
+		{"pub_signals/sex", checkFailed, "invalid_pub_signal"},
+		{"proof", checkSkipped, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			check, ok := byName[c.name]
+			if !ok {
+				t.Fatalf("check %s is missing", c.name)
+			}
+			if check.Status != c.status {
+				t.Fatalf("expected status %s, got %s", c.status, check.Status)
+			}
+			if code := check.Code; (code == nil) != (c.code == "") || code != nil && *code != c.code {
+				t.Fatalf("expected code %q, got %v", c.code, code)
+			}
+		})
+	}
+
+	if len(checks) != len(proofChecks)+2 {
+		t.Fatalf("expected %d checks, got %d", len(proofChecks)+2, len(checks))
+	}
+}
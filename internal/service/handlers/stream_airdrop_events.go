@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/config"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+)
+
+const (
+	// heartbeatInterval is the period of keep-alive comments, which also
+	// checks the DB for the updates made by the broadcaster in another process
+	heartbeatInterval = 15 * time.Second
+	// maxStreamDuration limits the stream, so that the connections are not
+	// held forever, the client reconnects with Last-Event-ID
+	maxStreamDuration = 10 * time.Minute
+)
+
+// StreamLimiter limits the concurrent streams in total and per nullifier, so
+// the clients can't exhaust the DB connections with the streams polling
+type StreamLimiter struct {
+	limits config.StreamLimits
+
+	mu          sync.Mutex
+	total       int
+	byNullifier map[string]int
+}
+
+func NewStreamLimiter(limits config.StreamLimits) *StreamLimiter {
+	return &StreamLimiter{
+		limits:      limits,
+		byNullifier: make(map[string]int),
+	}
+}
+
+// acquire reserves the stream, false is returned when a limit is reached
+func (l *StreamLimiter) acquire(nullifier string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total >= l.limits.MaxStreams || l.byNullifier[nullifier] >= l.limits.MaxPerNullifier {
+		return false
+	}
+
+	l.total++
+	l.byNullifier[nullifier]++
+	return true
+}
+
+func (l *StreamLimiter) release(nullifier string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.byNullifier[nullifier]--; l.byNullifier[nullifier] <= 0 {
+		delete(l.byNullifier, nullifier)
+	}
+}
+
+// StreamAirdropEvents streams the status transitions of the airdrops with the
+// nullifier as Server-Sent Events. The event ID is the transition ID, so the
+// client resumes from the last received event with Last-Event-ID header.
+func StreamAirdropEvents(w http.ResponseWriter, r *http.Request) {
+	nullifier, err := requests.NewGetAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	var lastID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		if lastID, err = strconv.ParseInt(header, 10, 64); err != nil {
+			ape.RenderErr(w, problems.BadRequest(errors.New("Last-Event-ID must be an integer"))...)
+			return
+		}
+	}
+
+	limiter := Streams(r)
+	if !limiter.acquire(nullifier) {
+		ape.RenderErr(w, problems.TooManyRequests())
+		return
+	}
+	defer limiter.release(nullifier)
+
+	// the server write timeout is too short for the stream
+	rc := http.NewResponseController(w)
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		Log(r).WithError(err).Error("Failed to disable write deadline for stream")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	sub := AirdropUpdates(r).Subscribe()
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var (
+		airdrops  = make(map[string]struct{})
+		heartbeat = time.NewTicker(heartbeatInterval)
+		deadline  = time.After(maxStreamDuration)
+	)
+	defer heartbeat.Stop()
+
+	// send writes the events recorded after the last sent one
+	send := func() error {
+		events, err := EventsQ(r).FilterByNullifier(nullifier).FilterAfter(lastID).Select()
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			payload, err := json.Marshal(toAirdropEvent(e))
+			if err != nil {
+				return fmt.Errorf("marshal event: %w", err)
+			}
+			if _, err = fmt.Fprintf(w, "id: %d\nevent: status\ndata: %s\n\n", e.ID, payload); err != nil {
+				return fmt.Errorf("write event: %w", err)
+			}
+
+			lastID = e.ID
+			airdrops[e.AirdropID] = struct{}{}
+		}
+
+		return rc.Flush()
+	}
+
+	err = send()
+	for err == nil {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline:
+			return
+		case ids := <-sub.C:
+			for _, id := range ids {
+				if _, ok := airdrops[id]; ok {
+					err = send()
+					break
+				}
+			}
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err == nil {
+				err = send()
+			}
+		}
+	}
+
+	// the write errors are expected on client disconnect
+	Log(r).WithError(err).Warn("Airdrop events stream failed")
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/cosmos/cosmos-sdk/types"
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rarimo/airdrop-svc/internal/service/requests"
+	"github.com/rarimo/airdrop-svc/resources"
+	zk "github.com/rarimo/zkverifier-kit"
+	"github.com/rarimo/zkverifier-kit/identity"
+	"gitlab.com/distributed_lab/ape"
+	"gitlab.com/distributed_lab/ape/problems"
+	"gitlab.com/distributed_lab/logan/v3"
+)
+
+const (
+	checkPassed  = "passed"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+)
+
+// VerifyAirdrop verifies the proof in the same way as CreateAirdrop does, but
+// never creates the airdrop, rendering the result of each check
+func VerifyAirdrop(w http.ResponseWriter, r *http.Request) {
+	req, err := requests.NewCreateAirdrop(r)
+	if err != nil {
+		ape.RenderErr(w, problems.BadRequest(err)...)
+		return
+	}
+
+	addr, err := types.AccAddressFromBech32(req.Data.Attributes.Address)
+	if err != nil {
+		Log(r).WithError(err).WithFields(logan.F{
+			"address": req.Data.Attributes.Address,
+		}).Error("Failed to decode hex ethereum address")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	proof := req.Data.Attributes.ZkProof
+	err = Verifier(r).VerifyProof(proof, zk.WithEventData(addr.Bytes()))
+	if errors.Is(err, identity.ErrContractCall) {
+		Log(r).WithError(err).Error("Failed to verify proof")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+
+	var errs val.Errors
+	if err != nil && !errors.As(err, &errs) {
+		Log(r).WithError(err).Error("Unexpected proof verification error")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if isMalformedProof(errs) {
+		renderProofErrors(w, errs)
+		return
+	}
+
+	// groth16 verification is done only when the signals are valid
+	proofVerified := err == nil || errs[proofField] != nil
+
+	nullifier := proof.PubSignals[zk.Nullifier]
+	airdrop, err := getActiveAirdrop(r, nullifier)
+	if err != nil {
+		Log(r).WithError(err).Error("Failed to get airdrop by nullifier")
+		ape.RenderErr(w, problems.InternalError())
+		return
+	}
+	if airdrop != nil && errs["pub_signals/nullifier"] == nil {
+		if errs == nil {
+			errs = val.Errors{}
+		}
+		errs[nullifierUsedField] = fmt.Errorf("nullifier is already used by %s airdrop", airdrop.Status)
+	}
+
+	checks := toProofChecks(errs, proofVerified)
+	valid := true
+	for _, c := range checks {
+		valid = valid && c.Status == checkPassed
+	}
+
+	ape.Render(w, resources.ProofVerificationResponse{
+		Data: resources.ProofVerification{
+			Key: resources.Key{
+				ID:   nullifier,
+				Type: resources.PROOF_VERIFICATION,
+			},
+			Attributes: resources.ProofVerificationAttributes{
+				Valid:  valid,
+				Checks: checks,
+			},
+		},
+	})
+}
+
+// toProofChecks converts the verification errors into the results of the
+// checks. The unknown fields are reported as separate checks, so no failure
+// is lost.
+func toProofChecks(errs val.Errors, proofVerified bool) []resources.ProofCheck {
+	var (
+		res   = make([]resources.ProofCheck, 0, len(proofChecks)+1)
+		known = map[string]struct{}{proofField: {}}
+	)
+
+	for _, check := range proofChecks {
+		var (
+			err  error
+			code = check.code
+		)
+		for _, f := range check.fields {
+			known[f.key] = struct{}{}
+			if err == nil && errs[f.key] != nil {
+				err = errs[f.key]
+				code, _ = proofErrorSource(f.key)
+			}
+		}
+
+		res = append(res, newProofCheck(check.name, code, err))
+	}
+
+	var unknown []string
+	for field := range errs {
+		if _, ok := known[field]; !ok {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	for _, field := range unknown {
+		res = append(res, newProofCheck(field, codeInvalidPubSignal, errs[field]))
+	}
+
+	if proofVerified {
+		res = append(res, newProofCheck("proof", codeInvalidProof, errs[proofField]))
+	} else {
+		res = append(res, resources.ProofCheck{Name: "proof", Status: checkSkipped})
+	}
+
+	return res
+}
+
+// newProofCheck returns the result of the check with the same code as
+// CreateAirdrop renders on failure
+func newProofCheck(name, code string, err error) resources.ProofCheck {
+	if err == nil {
+		return resources.ProofCheck{Name: name, Status: checkPassed}
+	}
+
+	reason := err.Error()
+	return resources.ProofCheck{Name: name, Status: checkFailed, Code: &code, Reason: &reason}
+}
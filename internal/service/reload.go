@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/config"
+	"gitlab.com/distributed_lab/kit/kv"
+	"gitlab.com/distributed_lab/logan/v3"
+)
+
+// reloadPollInterval is the period of the config file modification checks
+const reloadPollInterval = 10 * time.Second
+
+// WatchVerifier reloads the verifier rules on the signals from hup and on the
+// config file change. The file is polled instead of watched, because the
+// mounted configs are replaced by swapping the symlinks, which the watchers
+// miss. SIGHUP must be subscribed by the caller before the services start, so
+// the signal never terminates the process, even if the reload is disabled.
+func WatchVerifier(ctx context.Context, cfg *config.Config, hup <-chan os.Signal) {
+	log := cfg.Log().WithField("service", "verifier-reload")
+
+	path := os.Getenv(kv.EnvViperConfigFile)
+	if path == "" {
+		log.Warnf("%s is not set, verifier reload is disabled and SIGHUP is ignored", kv.EnvViperConfigFile)
+		return
+	}
+
+	ticker := time.NewTicker(reloadPollInterval)
+	defer ticker.Stop()
+
+	modTime := fileModTime(log, path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Info("Received SIGHUP, reloading verifier")
+		case <-ticker.C:
+			mt := fileModTime(log, path)
+			if mt.Equal(modTime) {
+				continue
+			}
+			modTime = mt
+			log.Info("Config file changed, reloading verifier")
+		}
+
+		// the getter reads the file once, so the new one is made on each reload
+		v, err := cfg.ReloadVerifier(kv.NewViperFile(path))
+		if err != nil {
+			log.WithError(err).Error("Failed to reload verifier, keeping the current one")
+			continue
+		}
+
+		log.WithFields(logan.F{
+			"event_id":             v.Params.EventID,
+			"query_selector":       v.Params.QuerySelector,
+			"min_age":              v.Params.MinAge,
+			"allowed_citizenships": v.Params.Citizenships,
+			"max_identity_count":   v.Params.MaxIdentityCount,
+			"airdrop_start":        v.Params.AirdropStart,
+		}).Info("Verifier reloaded")
+	}
+}
+
+// fileModTime returns the modification time of the file, the zero time is
+// returned on failure, so the file is reloaded once it is accessible again
+func fileModTime(log *logan.Entry, path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.WithError(err).Warn("Failed to stat config file")
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
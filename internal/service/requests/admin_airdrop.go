@@ -0,0 +1,19 @@
+package requests
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi"
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func NewAdminAirdrop(r *http.Request) (id string, err error) {
+	id = chi.URLParam(r, "id")
+
+	err = val.Errors{
+		"{id}": val.Validate(id, val.Required, is.UUID),
+	}.Filter()
+
+	return
+}
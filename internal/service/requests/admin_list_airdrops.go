@@ -0,0 +1,84 @@
+package requests
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+const maxPageLimit = 100
+
+var isAirdropStatus = val.In(
+	data.TxStatusPending,
+	data.TxStatusProcessing,
+	data.TxStatusSubmitted,
+	data.TxStatusCompleted,
+	data.TxStatusFailed,
+	data.TxStatusQuarantined,
+	data.TxStatusCancelled,
+)
+
+type ListAirdrops struct {
+	Statuses      []string
+	Address       string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	pgdb.OffsetPageParams
+}
+
+func NewListAirdrops(r *http.Request) (req ListAirdrops, err error) {
+	var (
+		query = r.URL.Query()
+		errs  = val.Errors{}
+	)
+
+	if status := query.Get("filter[status]"); status != "" {
+		req.Statuses = strings.Split(status, ",")
+	}
+	req.Address = query.Get("filter[address]")
+	req.CreatedAfter, errs["filter[created_after]"] = parseTime(query, "filter[created_after]")
+	req.CreatedBefore, errs["filter[created_before]"] = parseTime(query, "filter[created_before]")
+	req.Limit, errs["page[limit]"] = parseUint(query, "page[limit]")
+	req.PageNumber, errs["page[number]"] = parseUint(query, "page[number]")
+	req.Order = query.Get("page[order]")
+
+	errs["filter[status]"] = val.Validate(req.Statuses, val.Each(isAirdropStatus))
+	errs["filter[address]"] = val.Validate(req.Address, val.When(req.Address != "", isRarimoAddr))
+	errs["page[limit]"] = firstErr(errs["page[limit]"], val.Validate(req.Limit, val.Max(uint64(maxPageLimit))))
+	errs["page[order]"] = val.Validate(req.Order, val.In(pgdb.OrderTypeAsc, pgdb.OrderTypeDesc))
+
+	return req, errs.Filter()
+}
+
+func parseTime(query url.Values, key string) (time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, raw)
+}
+
+func parseUint(query url.Values, key string) (uint64, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
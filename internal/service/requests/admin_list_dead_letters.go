@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"net/http"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+type ListDeadLetters struct {
+	Subscription string
+	pgdb.OffsetPageParams
+}
+
+func NewListDeadLetters(r *http.Request) (req ListDeadLetters, err error) {
+	var (
+		query = r.URL.Query()
+		errs  = val.Errors{}
+	)
+
+	req.Subscription = query.Get("filter[subscription]")
+	req.Limit, errs["page[limit]"] = parseUint(query, "page[limit]")
+	req.PageNumber, errs["page[number]"] = parseUint(query, "page[number]")
+	req.Order = query.Get("page[order]")
+
+	errs["page[limit]"] = firstErr(errs["page[limit]"], val.Validate(req.Limit, val.Max(uint64(maxPageLimit))))
+	errs["page[order]"] = val.Validate(req.Order, val.In(pgdb.OrderTypeAsc, pgdb.OrderTypeDesc))
+
+	return req, errs.Filter()
+}
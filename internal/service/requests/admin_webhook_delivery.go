@@ -0,0 +1,23 @@
+package requests
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func NewAdminWebhookDelivery(r *http.Request) (id int64, err error) {
+	raw := chi.URLParam(r, "id")
+
+	err = val.Errors{
+		"{id}": val.Validate(raw, val.Required, is.Int),
+	}.Filter()
+	if err != nil {
+		return
+	}
+
+	return strconv.ParseInt(raw, 10, 64)
+}
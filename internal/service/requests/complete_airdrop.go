@@ -0,0 +1,38 @@
+package requests
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/rarimo/airdrop-svc/resources"
+)
+
+var txHashRegexp = regexp.MustCompile("^[0-9A-Fa-f]{64}$")
+
+type CompleteAirdrop struct {
+	ID     string
+	TxHash string
+}
+
+func NewCompleteAirdrop(r *http.Request) (req CompleteAirdrop, err error) {
+	if req.ID, err = NewAdminAirdrop(r); err != nil {
+		return req, err
+	}
+
+	var body resources.CompleteAirdropRequest
+	if err = json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return req, newDecodeError("body", err)
+	}
+
+	attr := body.Data.Attributes
+	// hashes are rendered uppercase by Cosmos
+	req.TxHash = strings.ToUpper(attr.TxHash)
+
+	return req, val.Errors{
+		"data/type":               val.Validate(body.Data.Type, val.Required, val.In(resources.COMPLETE_AIRDROP)),
+		"data/attributes/tx_hash": val.Validate(attr.TxHash, val.Required, val.Match(txHashRegexp)),
+	}.Filter()
+}
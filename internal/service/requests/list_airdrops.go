@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"net/http"
+	"strings"
+
+	val "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"gitlab.com/distributed_lab/kit/pgdb"
+)
+
+// ListUserAirdrops is the airdrops lookup by the destination address. The
+// cursor is the ID of the last airdrop from the previous page.
+type ListUserAirdrops struct {
+	Address  string
+	Statuses []string
+	Cursor   string
+	Limit    uint64
+	Order    string
+}
+
+func NewListUserAirdrops(r *http.Request) (req ListUserAirdrops, err error) {
+	var (
+		query = r.URL.Query()
+		errs  = val.Errors{}
+	)
+
+	req.Address = query.Get("filter[address]")
+	if status := query.Get("filter[status]"); status != "" {
+		req.Statuses = strings.Split(status, ",")
+	}
+	req.Cursor = query.Get("page[cursor]")
+	req.Limit, errs["page[limit]"] = parseUint(query, "page[limit]")
+	req.Order = query.Get("page[order]")
+
+	if req.Limit == 0 {
+		req.Limit = 15
+	}
+	if req.Order == "" {
+		req.Order = pgdb.OrderTypeDesc
+	}
+
+	errs["filter[address]"] = val.Validate(req.Address, val.Required, isRarimoAddr)
+	errs["filter[status]"] = val.Validate(req.Statuses, val.Each(isAirdropStatus))
+	errs["page[cursor]"] = val.Validate(req.Cursor, is.UUID)
+	errs["page[limit]"] = firstErr(errs["page[limit]"], val.Validate(req.Limit, val.Max(uint64(maxPageLimit))))
+	errs["page[order]"] = val.Validate(req.Order, val.In(pgdb.OrderTypeAsc, pgdb.OrderTypeDesc))
+
+	return req, errs.Filter()
+}
@@ -3,15 +3,14 @@ package service
 import (
 	"context"
 
-	"github.com/cosmos/cosmos-sdk/types"
 	"github.com/go-chi/chi"
+	"github.com/rarimo/airdrop-svc/internal/auth"
 	"github.com/rarimo/airdrop-svc/internal/config"
 	"github.com/rarimo/airdrop-svc/internal/service/handlers"
 	"gitlab.com/distributed_lab/ape"
 )
 
 func Run(ctx context.Context, cfg *config.Config) {
-	setBech32Prefixes()
 	r := chi.NewRouter()
 
 	r.Use(
@@ -19,26 +18,46 @@ func Run(ctx context.Context, cfg *config.Config) {
 		ape.LoganMiddleware(cfg.Log()),
 		ape.CtxMiddleware(
 			handlers.CtxLog(cfg.Log()),
-			handlers.CtxVerifier(cfg.Verifier().ZkVerifier),
-			handlers.CtxAirdropAmount(cfg.Broadcaster().AirdropCoins.String()),
-			handlers.CtxAirdropParams(cfg.Verifier().Params),
+			handlers.CtxVerifier(cfg),
+			handlers.CtxAirdropAmount(cfg.AirdropAmount().String()),
+			handlers.CtxStats(handlers.NewStatsCache(cfg.StatsCacheInterval())),
+			handlers.CtxAirdropUpdates(cfg.AirdropUpdates()),
+			handlers.CtxStreams(handlers.NewStreamLimiter(cfg.StreamLimits())),
 		),
 		handlers.DBCloneMiddleware(cfg.DB()),
 	)
 	r.Route("/integrations/airdrop-svc/airdrops", func(r chi.Router) {
 		r.Post("/", handlers.CreateAirdrop)
+		r.Get("/", handlers.ListAirdrops)
+		r.Post("/verify", handlers.VerifyAirdrop)
 		r.Get("/{nullifier}", handlers.GetAirdrop)
+		r.Get("/{nullifier}/timeline", handlers.GetAirdropTimeline)
+		r.Get("/{nullifier}/events", handlers.StreamAirdropEvents)
 		r.Get("/params", handlers.GetAirdropParams)
+		r.Get("/balance", handlers.GetAirdropBalance)
+		r.Get("/stats", handlers.GetAirdropStats)
+	})
+
+	// the public endpoints ignore the Authorization header, which may be set
+	// by the clients or gateways for other purposes
+	authenticate := handlers.AuthMiddleware(cfg.Auth())
+
+	r.Route("/integrations/airdrop-svc/admin/airdrops", func(r chi.Router) {
+		r.Use(authenticate, handlers.RequireScope(auth.ScopeAdmin))
+		r.Get("/", handlers.AdminListAirdrops)
+		r.Get("/{id}", handlers.AdminGetAirdrop)
+		r.Get("/{id}/timeline", handlers.AdminGetAirdropTimeline)
+		r.Post("/{id}/retry", handlers.AdminRetryAirdrop)
+		r.Post("/{id}/cancel", handlers.AdminCancelAirdrop)
+		r.Post("/{id}/complete", handlers.AdminCompleteAirdrop)
+	})
+
+	r.Route("/integrations/airdrop-svc/admin/webhooks", func(r chi.Router) {
+		r.Use(authenticate, handlers.RequireScope(auth.ScopeAdmin))
+		r.Get("/dead-letters", handlers.AdminListDeadLetters)
+		r.Post("/dead-letters/{id}/redeliver", handlers.AdminRedeliverWebhook)
 	})
 
 	cfg.Log().Info("Service started")
 	ape.Serve(ctx, r, cfg, ape.ServeOpts{})
 }
-
-func setBech32Prefixes() {
-	c := types.GetConfig()
-	c.SetBech32PrefixForAccount("rarimo", "rarimopub")
-	c.SetBech32PrefixForValidator("rarimovaloper", "rarimovaloperpub")
-	c.SetBech32PrefixForConsensusNode("rarimovalcons", "rarimovalconspub")
-	c.Seal()
-}
@@ -0,0 +1,24 @@
+package signer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cosmos/cosmos-sdk/crypto"
+)
+
+// FromKeystore creates the signer from the passphrase-encrypted armored
+// private key file, e.g. exported with `rarimo-cored keys export`
+func FromKeystore(path, passphrase string) (Signer, error) {
+	armor, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore file: %w", err)
+	}
+
+	key, _, err := crypto.UnarmorDecryptPrivKey(string(armor), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %w", err)
+	}
+
+	return FromPrivKey(key), nil
+}
@@ -0,0 +1,148 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const signPath = "/sign"
+
+var (
+	// ErrUnavailable is returned when the remote signer can't be reached or
+	// fails internally, so the signing may be retried later
+	ErrUnavailable = errors.New("remote signer is unavailable")
+	// ErrRejected is returned when the remote signer refuses to sign or
+	// returns an invalid signature, which is caused by its misconfiguration,
+	// e.g. the unknown public key
+	ErrRejected = errors.New("remote signer rejected the request")
+)
+
+// SignRequest is the body of the remote signer request. The public key
+// identifies the key to sign with, when the signer holds several keys.
+type SignRequest struct {
+	PubKey    string `json:"pub_key"`
+	SignBytes []byte `json:"sign_bytes"`
+}
+
+type SignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type remoteSigner struct {
+	url    string
+	pubKey cryptotypes.PubKey
+	client *http.Client
+}
+
+// NewRemote creates the signer which delegates signing to the HTTP service.
+// The public key must be known in advance: it is used to derive the sender
+// address and to verify the returned signatures.
+func NewRemote(endpoint, pubKeyHex string, timeout time.Duration) (Signer, error) {
+	u, err := url.JoinPath(endpoint, signPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote signer url: %w", err)
+	}
+
+	pubKey, err := hexutil.Decode(pubKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("public key is not a hex string: %w", err)
+	}
+	if len(pubKey) != secp256k1.PubKeySize {
+		return nil, fmt.Errorf("invalid compressed public key length %d", len(pubKey))
+	}
+
+	return &remoteSigner{
+		url:    u,
+		pubKey: &secp256k1.PubKey{Key: pubKey},
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *remoteSigner) PubKey() cryptotypes.PubKey {
+	return s.pubKey
+}
+
+func (s *remoteSigner) Sign(ctx context.Context, msg []byte) ([]byte, error) {
+	body, err := json.Marshal(SignRequest{
+		PubKey:    hexutil.Encode(s.pubKey.Bytes()),
+		SignBytes: msg,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal sign request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create sign request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: send sign request: %w", ErrUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrRejected, resp.StatusCode)
+	}
+
+	var res SignResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("%w: decode sign response: %w", ErrRejected, err)
+	}
+
+	if !s.pubKey.VerifySignature(msg, res.Signature) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrRejected)
+	}
+
+	return res.Signature, nil
+}
+
+// NewHandler serves the remote signer API with the given key. It is a local
+// stand-in of the real remote signer for development and tests.
+func NewHandler(key cryptotypes.PrivKey) http.Handler {
+	pubKey := hexutil.Encode(key.PubKey().Bytes())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(signPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req SignRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.PubKey != pubKey {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		sig, err := key.Sign(req.SignBytes)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(SignResponse{Signature: sig})
+	})
+
+	return mux
+}
@@ -0,0 +1,88 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var signBytes = []byte("sign bytes")
+
+func newTestRemote(t *testing.T, handler http.Handler, key *secp256k1.PrivKey) Signer {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	s, err := NewRemote(srv.URL, hexutil.Encode(key.PubKey().Bytes()), time.Second)
+	if err != nil {
+		t.Fatalf("create remote signer: %v", err)
+	}
+
+	return s
+}
+
+func TestRemoteSign(t *testing.T) {
+	key := secp256k1.GenPrivKey()
+	s := newTestRemote(t, NewHandler(key), key)
+
+	sig, err := s.Sign(context.Background(), signBytes)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if !key.PubKey().VerifySignature(signBytes, sig) {
+		t.Fatal("signature is not valid for the key")
+	}
+	if !s.PubKey().Equals(key.PubKey()) {
+		t.Fatal("public key doesn't match the key")
+	}
+}
+
+func TestRemoteSignUnavailable(t *testing.T) {
+	key := secp256k1.GenPrivKey()
+	s := newTestRemote(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}), key)
+
+	_, err := s.Sign(context.Background(), signBytes)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("expected ErrUnavailable, got %v", err)
+	}
+}
+
+func TestRemoteSignUnknownKey(t *testing.T) {
+	key := secp256k1.GenPrivKey()
+	s := newTestRemote(t, NewHandler(secp256k1.GenPrivKey()), key)
+
+	_, err := s.Sign(context.Background(), signBytes)
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+}
+
+func TestRemoteSignInvalidSignature(t *testing.T) {
+	key := secp256k1.GenPrivKey()
+	s := newTestRemote(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		sig, err := secp256k1.GenPrivKey().Sign(signBytes)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(SignResponse{Signature: sig})
+	}), key)
+
+	_, err := s.Sign(context.Background(), signBytes)
+	if !errors.Is(err, ErrRejected) {
+		t.Fatalf("expected ErrRejected, got %v", err)
+	}
+	if errors.Is(err, ErrUnavailable) {
+		t.Fatalf("invalid signature must not be retried as unavailable: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+// Package signer provides the signing backends of the airdrop sender, so that
+// the private key does not have to be stored in plain config.
+package signer
+
+import (
+	"context"
+
+	cryptotypes "github.com/cosmos/cosmos-sdk/crypto/types"
+)
+
+// Signer signs the tx sign bytes on behalf of the sender account
+type Signer interface {
+	PubKey() cryptotypes.PubKey
+	Sign(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+type privKeySigner struct {
+	key cryptotypes.PrivKey
+}
+
+// FromPrivKey creates the signer with the private key kept in memory
+func FromPrivKey(key cryptotypes.PrivKey) Signer {
+	return &privKeySigner{key: key}
+}
+
+func (s *privKeySigner) PubKey() cryptotypes.PubKey {
+	return s.key.PubKey()
+}
+
+func (s *privKeySigner) Sign(_ context.Context, msg []byte) ([]byte, error) {
+	return s.key.Sign(msg)
+}
@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/config"
+	"github.com/rarimo/airdrop-svc/internal/data"
+)
+
+// Headers of the webhook request. The receiver must verify the signature and
+// reject the stale timestamps to prevent replays. The delivery ID is the same
+// for all the attempts, so it can be used to drop duplicates.
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// maxResponseSize limits the response body read to reuse the connection
+const maxResponseSize = 64 << 10
+
+// Sign returns the signature of the webhook body sent at the timestamp (Unix
+// seconds): hex-encoded HMAC-SHA256 of "<timestamp>.<body>" with the
+// subscription secret, prefixed with "sha256=".
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// send posts the signed payload to the subscription endpoint, any non-2xx
+// response is an error
+func send(ctx context.Context, client *http.Client, s config.WebhookSubscription, d data.Delivery, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(s.Secret, timestamp, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
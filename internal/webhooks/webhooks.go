@@ -0,0 +1,292 @@
+// Package webhooks delivers the airdrop status changes to the partners. The
+// events are enqueued in the outbox in the same statement as the status
+// update, then each outbox entry is fanned out to the matching subscriptions,
+// and the deliveries are sent with exponential backoff until the attempts are
+// exhausted.
+//
+// The rows are locked with SKIP LOCKED, so the worker may run in each replica
+// of the service.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rarimo/airdrop-svc/internal/config"
+	"github.com/rarimo/airdrop-svc/internal/data"
+	"github.com/rarimo/airdrop-svc/resources"
+	"gitlab.com/distributed_lab/logan/v3"
+	"gitlab.com/distributed_lab/running"
+)
+
+// leaseMargin prolongs the claim of the delivery over the request timeout, so
+// the delivery is not retried while the worker is still updating its status
+const leaseMargin = 30 * time.Second
+
+type worker struct {
+	log        *logan.Entry
+	outbox     *data.OutboxQ
+	deliveries *data.DeliveriesQ
+	events     *data.EventsQ
+	airdrops   *data.AirdropsQ
+	client     *http.Client
+	// subscriptions are indexed by name, which is stored in the deliveries
+	subscriptions map[string]config.WebhookSubscription
+	config.Webhooks
+}
+
+func Run(ctx context.Context, cfg *config.Config) {
+	log := cfg.Log().WithField("service", "webhooks")
+	log.Info("Starting service")
+
+	// the outbox entries are dispatched in the same transaction as the
+	// deliveries are created, so the queries share the connection
+	db := cfg.DB().Clone()
+	w := &worker{
+		log:        log,
+		outbox:     data.NewOutboxQ(db),
+		deliveries: data.NewDeliveriesQ(db),
+		events:     data.NewEventsQ(cfg.DB().Clone()),
+		// the airdrops are only read, so the actor is never recorded
+		airdrops:      data.NewAirdropsQ(cfg.DB().Clone(), data.ActorBroadcaster),
+		subscriptions: make(map[string]config.WebhookSubscription),
+		Webhooks:      cfg.Webhooks(),
+	}
+	w.client = &http.Client{Timeout: w.Timeout}
+	for _, s := range w.Subscriptions {
+		w.subscriptions[s.Name] = s
+	}
+	log.Infof("Delivering webhooks to %d subscriptions", len(w.Subscriptions))
+
+	running.WithBackOff(ctx, log, "webhooks-worker", w.run, w.PollInterval, w.PollInterval, time.Minute)
+}
+
+// run dispatches the whole outbox and sends the deliveries which backoff has
+// passed
+func (w *worker) run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		n, err := w.dispatch()
+		if err != nil {
+			return fmt.Errorf("dispatch outbox: %w", err)
+		}
+		if uint64(n) < w.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		n, err := w.deliver(ctx)
+		if err != nil {
+			return fmt.Errorf("deliver webhooks: %w", err)
+		}
+		if uint64(n) < w.BatchSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+// dispatch creates the deliveries of a page of outbox entries to the matching
+// subscriptions, returning the amount of dispatched entries
+func (w *worker) dispatch() (int, error) {
+	var dispatched int
+
+	err := w.outbox.Transaction(func() error {
+		entries, err := w.outbox.New().SelectUndispatched(w.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+
+		var (
+			ids        = make([]int64, len(entries))
+			deliveries []data.Delivery
+		)
+		for i, e := range entries {
+			ids[i] = e.ID
+			for _, s := range w.Subscriptions {
+				if s.Accepts(e.EventStatus) {
+					deliveries = append(deliveries, data.Delivery{EventID: e.EventID, Subscription: s.Name})
+				}
+			}
+		}
+
+		if err = w.deliveries.New().Insert(deliveries); err != nil {
+			return err
+		}
+		dispatched = len(entries)
+		return w.outbox.New().Delete(ids)
+	})
+
+	return dispatched, err
+}
+
+// deliver sends a page of the ready deliveries, returning their amount. The
+// subscriptions are served concurrently, so a slow endpoint doesn't delay the
+// others.
+func (w *worker) deliver(ctx context.Context) (int, error) {
+	if len(w.subscriptions) == 0 {
+		return 0, nil
+	}
+
+	names := make([]string, 0, len(w.subscriptions))
+	for name := range w.subscriptions {
+		names = append(names, name)
+	}
+
+	deliveries, err := w.deliveries.New().Claim(names, w.BatchSize, w.Timeout+leaseMargin)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	payloads, err := w.payloads(deliveries)
+	if err != nil {
+		// the deliveries are retried after the lease
+		return 0, fmt.Errorf("build payloads: %w", err)
+	}
+
+	bySubscription := make(map[string][]data.Delivery)
+	for _, d := range deliveries {
+		bySubscription[d.Subscription] = append(bySubscription[d.Subscription], d)
+	}
+
+	var wg sync.WaitGroup
+	for name, batch := range bySubscription {
+		wg.Add(1)
+		go func(s config.WebhookSubscription, batch []data.Delivery) {
+			defer wg.Done()
+			for _, d := range batch {
+				w.attempt(ctx, s, d, payloads[d.EventID])
+			}
+		}(w.subscriptions[name], batch)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// attempt sends the delivery and saves the result: the failed delivery is
+// rescheduled with exponential backoff or moved to the dead letters, when
+// the attempts are exhausted
+func (w *worker) attempt(ctx context.Context, s config.WebhookSubscription, d data.Delivery, payload []byte) {
+	log := w.log.WithFields(logan.F{
+		"subscription": s.Name,
+		"delivery":     d.ID,
+		"event":        d.EventID,
+		"attempt":      d.Attempts,
+	})
+
+	err := send(ctx, w.client, s, d, payload)
+	if ctx.Err() != nil {
+		// the delivery is retried after the lease
+		return
+	}
+
+	values := map[string]any{
+		"status":     data.DeliveryStatusDelivered,
+		"last_error": nil,
+	}
+	if err != nil {
+		values["last_error"] = err.Error()
+		if d.Attempts < w.MaxAttempts {
+			backoff := config.RetryBackoff(d.Attempts, w.Backoff, w.MaxBackoff)
+			values["status"] = data.DeliveryStatusPending
+			values["next_attempt_at"] = data.NowAfter(backoff)
+			log.WithError(err).Debugf("Failed to deliver webhook, retrying in %s", backoff)
+		} else {
+			values["status"] = data.DeliveryStatusDead
+			log.WithError(err).Warn("Failed to deliver webhook, moving to dead letters")
+		}
+	}
+
+	if err = w.deliveries.New().Update(d.ID, values); err != nil {
+		// the delivery is retried after the lease, so it may be delivered
+		// twice, which must be handled by the receiver anyway
+		log.WithError(err).Error("Failed to save webhook delivery result")
+	}
+}
+
+// payloads renders the events of the deliveries with the airdrops included,
+// indexed by event ID
+func (w *worker) payloads(deliveries []data.Delivery) (map[int64][]byte, error) {
+	eventIDs := make([]int64, len(deliveries))
+	for i, d := range deliveries {
+		eventIDs[i] = d.EventID
+	}
+
+	events, err := w.events.New().FilterByID(eventIDs...).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	airdropIDs := make([]string, len(events))
+	for i, e := range events {
+		airdropIDs[i] = e.AirdropID
+	}
+
+	airdrops, err := w.airdrops.New().FilterByID(airdropIDs...).Select()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]data.Airdrop, len(airdrops))
+	for _, a := range airdrops {
+		byID[a.ID] = a
+	}
+
+	res := make(map[int64][]byte, len(events))
+	for _, e := range events {
+		if res[e.ID], err = newPayload(e, byID[e.AirdropID]); err != nil {
+			return nil, fmt.Errorf("render event %d: %w", e.ID, err)
+		}
+	}
+
+	return res, nil
+}
+
+// newPayload renders the event in the same way as the timeline endpoint does,
+// including the current state of the airdrop. The admin identity and the raw
+// error are not sent to the partners.
+func newPayload(e data.Event, a data.Airdrop) ([]byte, error) {
+	doc := resources.AirdropEventResponse{
+		Data: resources.AirdropEvent{
+			Key: resources.NewKeyInt64(e.ID, resources.AIRDROP_EVENT),
+			Attributes: resources.AirdropEventAttributes{
+				AirdropId: e.AirdropID,
+				OldStatus: e.OldStatus,
+				NewStatus: e.NewStatus,
+				TxHash:    e.TxHash,
+				Actor:     e.Actor,
+				CreatedAt: e.CreatedAt,
+			},
+		},
+	}
+
+	doc.Included.Add(&resources.Airdrop{
+		Key: resources.Key{
+			ID:   a.ID,
+			Type: resources.AIRDROP,
+		},
+		Attributes: resources.AirdropAttributes{
+			Nullifier: a.Nullifier,
+			Address:   a.Address,
+			TxHash:    a.TxHash,
+			Amount:    a.Amount,
+			Status:    a.Status,
+			CreatedAt: a.CreatedAt,
+			UpdatedAt: a.UpdatedAt,
+		},
+	})
+
+	return json.Marshal(doc)
+}
@@ -11,14 +11,22 @@ type AirdropAttributes struct {
 	Address string `json:"address"`
 	// Amount of airdropped coins
 	Amount string `json:"amount"`
+	// Amount of failed attempts to send the airdrop, admin only
+	Attempts *int32 `json:"attempts,omitempty"`
 	// RFC3339 UTC timestamp of the airdrop creation
 	CreatedAt time.Time `json:"created_at"`
+	// Reason of the last failed attempt, admin only
+	LastError *string `json:"last_error,omitempty"`
 	// User nullifier
 	Nullifier string `json:"nullifier"`
+	// Address which has sent the last airdrop tx, admin only
+	Sender *string `json:"sender,omitempty"`
 	// Status of the airdrop transaction
 	Status string `json:"status"`
 	// Hash of the airdrop transaction
 	TxHash *string `json:"tx_hash,omitempty"`
+	// Height of the block with the airdrop transaction, admin only
+	TxHeight *int64 `json:"tx_height,omitempty"`
 	// RFC3339 UTC timestamp of the airdrop successful tx
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,43 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "encoding/json"
+
+type AirdropEvent struct {
+	Key
+	Attributes AirdropEventAttributes `json:"attributes"`
+}
+type AirdropEventResponse struct {
+	Data     AirdropEvent `json:"data"`
+	Included Included     `json:"included"`
+}
+
+type AirdropEventListResponse struct {
+	Data     []AirdropEvent  `json:"data"`
+	Included Included        `json:"included"`
+	Links    *Links          `json:"links"`
+	Meta     json.RawMessage `json:"meta,omitempty"`
+}
+
+func (r *AirdropEventListResponse) PutMeta(v interface{}) (err error) {
+	r.Meta, err = json.Marshal(v)
+	return err
+}
+
+func (r *AirdropEventListResponse) GetMeta(out interface{}) error {
+	return json.Unmarshal(r.Meta, out)
+}
+
+// MustAirdropEvent - returns AirdropEvent from include collection.
+// if entry with specified key does not exist - returns nil
+// if entry with specified key exists but type or ID mismatches - panics
+func (c *Included) MustAirdropEvent(key Key) *AirdropEvent {
+	var airdropEvent AirdropEvent
+	if c.tryFindEntry(key, &airdropEvent) {
+		return &airdropEvent
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "time"
+
+type AirdropEventAttributes struct {
+	// Who made the transition, one of api, broadcaster or admin
+	Actor string `json:"actor"`
+	// Identity of the actor, e.g. the admin name
+	ActorId *string `json:"actor_id,omitempty"`
+	// Identifier of the airdrop
+	AirdropId string `json:"airdrop_id"`
+	// RFC3339 UTC timestamp of the transition
+	CreatedAt time.Time `json:"created_at"`
+	// Reason of the failed attempt
+	Error *string `json:"error,omitempty"`
+	// Status of the airdrop after the transition
+	NewStatus string `json:"new_status"`
+	// Status of the airdrop before the transition, absent on creation
+	OldStatus *string `json:"old_status,omitempty"`
+	// Hash of the airdrop transaction at the moment of the transition
+	TxHash *string `json:"tx_hash,omitempty"`
+}
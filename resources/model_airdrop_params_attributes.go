@@ -5,10 +5,22 @@
 package resources
 
 type AirdropParamsAttributes struct {
+	// Amount of a single airdrop
+	Amount string `json:"amount"`
+	// ISO 3166 alpha-3 codes of the allowed citizenships
+	AllowedCitizenships []string `json:"allowed_citizenships"`
 	// Event identifier that is generated during ZKP query creation
 	EventId string `json:"event_id"`
+	// Max amount of the reissued identities, the identity must satisfy either this or started_at limit
+	MaxIdentityCount int64 `json:"max_identity_count"`
+	// Minimal age of the user in years
+	MinAge int32 `json:"min_age"`
 	// Query selector that is used for proof generation
 	QuerySelector string `json:"query_selector"`
-	// Unix timestamp in seconds when airdrop event starts
+	// Total balance of the senders available for airdrops, absent when it is not known yet
+	RemainingBudget *string `json:"remaining_budget,omitempty"`
+	// Amount of airdrops that can be paid with the remaining budget, absent when it is not known yet
+	RemainingClaims *int64 `json:"remaining_claims,omitempty"`
+	// Unix timestamp in seconds when airdrop event starts, the identity must be created before it, unless max_identity_count limit is satisfied
 	StartedAt int64 `json:"started_at"`
 }
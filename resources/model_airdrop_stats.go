@@ -0,0 +1,43 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "encoding/json"
+
+type AirdropStats struct {
+	Key
+	Attributes AirdropStatsAttributes `json:"attributes"`
+}
+type AirdropStatsResponse struct {
+	Data     AirdropStats `json:"data"`
+	Included Included     `json:"included"`
+}
+
+type AirdropStatsListResponse struct {
+	Data     []AirdropStats  `json:"data"`
+	Included Included        `json:"included"`
+	Links    *Links          `json:"links"`
+	Meta     json.RawMessage `json:"meta,omitempty"`
+}
+
+func (r *AirdropStatsListResponse) PutMeta(v interface{}) (err error) {
+	r.Meta, err = json.Marshal(v)
+	return err
+}
+
+func (r *AirdropStatsListResponse) GetMeta(out interface{}) error {
+	return json.Unmarshal(r.Meta, out)
+}
+
+// MustAirdropStats - returns AirdropStats from include collection.
+// if entry with specified key does not exist - returns nil
+// if entry with specified key exists but type or ID mismatches - panics
+func (c *Included) MustAirdropStats(key Key) *AirdropStats {
+	var airdropStats AirdropStats
+	if c.tryFindEntry(key, &airdropStats) {
+		return &airdropStats
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "time"
+
+type AirdropStatsAttributes struct {
+	// Amount of airdrops by status
+	ByStatus map[string]int64 `json:"by_status"`
+	// Amount of airdrops created per day, the days without airdrops are omitted
+	ClaimsPerDay []DailyClaims `json:"claims_per_day"`
+	// Median time from the airdrop creation to completion in seconds, absent when there are no completed airdrops
+	MedianCompletionSeconds *float64 `json:"median_completion_seconds,omitempty"`
+	// Total amount of completed airdrops per denom
+	TotalDistributed string `json:"total_distributed"`
+	// RFC3339 UTC timestamp of the statistics computation
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "time"
+
+type BalanceAttributes struct {
+	// Sender balance available for new airdrops
+	Amount string `json:"amount"`
+	// Amount of airdrops that can be paid with the balance, fees are not accounted
+	FundableClaims int64 `json:"fundable_claims"`
+	// RFC3339 UTC timestamp of the oldest balance check
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,43 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "encoding/json"
+
+type CompleteAirdrop struct {
+	Key
+	Attributes CompleteAirdropAttributes `json:"attributes"`
+}
+type CompleteAirdropRequest struct {
+	Data     CompleteAirdrop `json:"data"`
+	Included Included        `json:"included"`
+}
+
+type CompleteAirdropListRequest struct {
+	Data     []CompleteAirdrop `json:"data"`
+	Included Included          `json:"included"`
+	Links    *Links            `json:"links"`
+	Meta     json.RawMessage   `json:"meta,omitempty"`
+}
+
+func (r *CompleteAirdropListRequest) PutMeta(v interface{}) (err error) {
+	r.Meta, err = json.Marshal(v)
+	return err
+}
+
+func (r *CompleteAirdropListRequest) GetMeta(out interface{}) error {
+	return json.Unmarshal(r.Meta, out)
+}
+
+// MustCompleteAirdrop - returns CompleteAirdrop from include collection.
+// if entry with specified key does not exist - returns nil
+// if entry with specified key exists but type or ID mismatches - panics
+func (c *Included) MustCompleteAirdrop(key Key) *CompleteAirdrop {
+	var completeAirdrop CompleteAirdrop
+	if c.tryFindEntry(key, &completeAirdrop) {
+		return &completeAirdrop
+	}
+	return nil
+}
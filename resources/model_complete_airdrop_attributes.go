@@ -0,0 +1,10 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+type CompleteAirdropAttributes struct {
+	// Hash of the transaction which has transferred the airdrop
+	TxHash string `json:"tx_hash"`
+}
@@ -0,0 +1,12 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+type DailyClaims struct {
+	// Amount of airdrops created on the day
+	Count int64 `json:"count"`
+	// UTC day in YYYY-MM-DD format
+	Date string `json:"date"`
+}
@@ -0,0 +1,16 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+type ProofCheck struct {
+	// Stable code of the failure, the same as airdrop creation renders, absent for passed and skipped checks
+	Code *string `json:"code,omitempty"`
+	// Name of the check
+	Name string `json:"name"`
+	// Reason of the failure, absent for passed and skipped checks
+	Reason *string `json:"reason,omitempty"`
+	// Result of the check, skipped when the check depends on the failed ones
+	Status string `json:"status"`
+}
@@ -0,0 +1,43 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "encoding/json"
+
+type ProofVerification struct {
+	Key
+	Attributes ProofVerificationAttributes `json:"attributes"`
+}
+type ProofVerificationResponse struct {
+	Data     ProofVerification `json:"data"`
+	Included Included          `json:"included"`
+}
+
+type ProofVerificationListResponse struct {
+	Data     []ProofVerification `json:"data"`
+	Included Included            `json:"included"`
+	Links    *Links              `json:"links"`
+	Meta     json.RawMessage     `json:"meta,omitempty"`
+}
+
+func (r *ProofVerificationListResponse) PutMeta(v interface{}) (err error) {
+	r.Meta, err = json.Marshal(v)
+	return err
+}
+
+func (r *ProofVerificationListResponse) GetMeta(out interface{}) error {
+	return json.Unmarshal(r.Meta, out)
+}
+
+// MustProofVerification - returns ProofVerification from include collection.
+// if entry with specified key does not exist - returns nil
+// if entry with specified key exists but type or ID mismatches - panics
+func (c *Included) MustProofVerification(key Key) *ProofVerification {
+	var proofVerification ProofVerification
+	if c.tryFindEntry(key, &proofVerification) {
+		return &proofVerification
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+type ProofVerificationAttributes struct {
+	// Results of the checks in the order of execution
+	Checks []ProofCheck `json:"checks"`
+	// Whether the airdrop would be created with the proof
+	Valid bool `json:"valid"`
+}
@@ -8,6 +8,12 @@ type ResourceType string
 
 // List of ResourceType
 const (
-	AIRDROP        ResourceType = "airdrop"
-	CREATE_AIRDROP ResourceType = "create_airdrop"
+	AIRDROP            ResourceType = "airdrop"
+	AIRDROP_EVENT      ResourceType = "airdrop_event"
+	AIRDROP_STATS      ResourceType = "airdrop_stats"
+	BALANCE            ResourceType = "balance"
+	COMPLETE_AIRDROP   ResourceType = "complete_airdrop"
+	CREATE_AIRDROP     ResourceType = "create_airdrop"
+	PROOF_VERIFICATION ResourceType = "proof_verification"
+	WEBHOOK_DELIVERY   ResourceType = "webhook_delivery"
 )
@@ -0,0 +1,43 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "encoding/json"
+
+type WebhookDelivery struct {
+	Key
+	Attributes WebhookDeliveryAttributes `json:"attributes"`
+}
+type WebhookDeliveryResponse struct {
+	Data     WebhookDelivery `json:"data"`
+	Included Included        `json:"included"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Data     []WebhookDelivery `json:"data"`
+	Included Included          `json:"included"`
+	Links    *Links            `json:"links"`
+	Meta     json.RawMessage   `json:"meta,omitempty"`
+}
+
+func (r *WebhookDeliveryListResponse) PutMeta(v interface{}) (err error) {
+	r.Meta, err = json.Marshal(v)
+	return err
+}
+
+func (r *WebhookDeliveryListResponse) GetMeta(out interface{}) error {
+	return json.Unmarshal(r.Meta, out)
+}
+
+// MustWebhookDelivery - returns WebhookDelivery from include collection.
+// if entry with specified key does not exist - returns nil
+// if entry with specified key exists but type or ID mismatches - panics
+func (c *Included) MustWebhookDelivery(key Key) *WebhookDelivery {
+	var webhookDelivery WebhookDelivery
+	if c.tryFindEntry(key, &webhookDelivery) {
+		return &webhookDelivery
+	}
+	return nil
+}
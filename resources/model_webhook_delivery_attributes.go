@@ -0,0 +1,30 @@
+/*
+ * GENERATED. Do not modify. Your changes might be overwritten!
+ */
+
+package resources
+
+import "time"
+
+type WebhookDeliveryAttributes struct {
+	// Identifier of the airdrop
+	AirdropId string `json:"airdrop_id"`
+	// Amount of the delivery attempts
+	Attempts int32 `json:"attempts"`
+	// RFC3339 UTC timestamp of the delivery creation
+	CreatedAt time.Time `json:"created_at"`
+	// Identifier of the delivered airdrop event
+	EventId string `json:"event_id"`
+	// Status of the airdrop after the delivered transition
+	EventStatus string `json:"event_status"`
+	// Reason of the last failed attempt
+	LastError *string `json:"last_error,omitempty"`
+	// User nullifier of the airdrop
+	Nullifier string `json:"nullifier"`
+	// Delivery status
+	Status string `json:"status"`
+	// Name of the webhook subscription
+	Subscription string `json:"subscription"`
+	// RFC3339 UTC timestamp of the last attempt
+	UpdatedAt time.Time `json:"updated_at"`
+}